@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// alignExtraID is the extra field which zipalign pads the local header with.
+	alignExtraID    = 0xd935
+	alignExtraLen   = 6
+	localHeaderLen  = 30
+	defaultAlign    = 4
+	maxAlign        = 1 << 15
+	alignRuleFormat = "PATTERN=N"
+)
+
+func newAlignCmd(params *cmdParams) *cobra.Command {
+	aligncmd := &aligner{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "align [filepath...]",
+		Short: "Align stored files (default 4 bytes)",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			aligncmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&aligncmd.check, "check", false, "report misaligned files without rewriting")
+	aligncmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type aligner struct {
+	*baseCmd
+	pexe  *toolParallelCmd
+	check bool
+}
+
+func (o *aligner) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if o.align == 0 {
+		o.align = defaultAlign
+	}
+	if _, err := o.generateAlignRules(); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	for _, filepath := range paths {
+		if strings.Contains(filepath, nestedSeparator) {
+			fmt.Fprintf(o.stderr, "%s: nested archive cannot be aligned\n", filepath)
+			return
+		}
+	}
+
+	if o.check {
+		o.checkFiles(paths)
+		return
+	}
+
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *aligner) execute(filepath string) error {
+	file, err := o.openInput(filepath)
+	if err != nil {
+		return err
+	}
+	defer close(file)
+
+	zr, err := zip.NewReader(file, file.size)
+	if err != nil {
+		return err
+	}
+
+	// writeOutput aligns the files copied as they are
+	return o.writeOutput(filepath, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		zw.SetComment(zr.Comment)
+		for _, zf := range zr.File {
+			if err := zw.CopyFile(zf); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}, file)
+}
+
+func (o *aligner) checkFiles(paths []string) {
+	if err := validateStdinPaths(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	for _, filepath := range paths {
+		if err := o.checkFile(filepath); err != nil {
+			fmt.Fprintln(o.stderr, err)
+			return
+		}
+	}
+}
+
+// checkFile prints the stored files of filepath whose data is not aligned.
+func (o *aligner) checkFile(filepath string) error {
+	file, err := o.openInput(filepath)
+	if err != nil {
+		return err
+	}
+	defer close(file)
+
+	zr, err := zip.NewReader(file, file.size)
+	if err != nil {
+		return err
+	}
+
+	for _, zf := range zr.File {
+		size, err := o.alignment(zf.Name)
+		if err != nil {
+			return err
+		}
+		if zf.Method != zip.Store || size <= 1 {
+			continue
+		}
+
+		offset, err := zf.DataOffset()
+		if err != nil {
+			return fmt.Errorf("%s: %s: %v", filepath, zf.Name, err)
+		}
+		if offset%int64(size) != 0 {
+			fmt.Fprintf(o.stdout, "%s: %s: data offset %d is not aligned to %d\n", filepath, zf.Name, offset, size)
+		}
+	}
+	return nil
+}
+
+type alignRule struct {
+	match nameMatcher
+	size  int
+}
+
+// isAligned reports whether the output archives are aligned.
+func (o *cmdParams) isAligned() bool {
+	return o.align != 0 || len(o.alignRules) != 0
+}
+
+func (o *cmdParams) generateAlignRules() ([]alignRule, error) {
+	o.alignOnce.Do(func() {
+		o.alignments, o.alignErr = o.compileAlignRules()
+	})
+	return o.alignments, o.alignErr
+}
+
+// compileAlignRules parses --align-pattern after checking --align.
+func (o *cmdParams) compileAlignRules() ([]alignRule, error) {
+	if o.align != 0 && !isAlignSize(o.align) {
+		return nil, fmt.Errorf("alignment must be a power of two up to %d", maxAlign)
+	}
+
+	rules := make([]alignRule, 0, len(o.alignRules))
+	for _, rule := range o.alignRules {
+		sep := strings.LastIndex(rule, "=")
+		if sep <= 0 {
+			return nil, fmt.Errorf("invalid alignment %q (%s)", rule, alignRuleFormat)
+		}
+		size, err := strconv.Atoi(rule[sep+1:])
+		if err != nil || !isAlignSize(size) {
+			return nil, fmt.Errorf("invalid alignment %q: alignment must be a power of two up to %d", rule, maxAlign)
+		}
+		m, err := o.globMatcher(rule[:sep])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, alignRule{m, size})
+	}
+	return rules, nil
+}
+
+func isAlignSize(n int) bool {
+	return 0 < n && n <= maxAlign && n&(n-1) == 0
+}
+
+// alignment returns the alignment of the stored file name.
+// The first matching --align-pattern overrides --align.
+func (o *cmdParams) alignment(name string) (int, error) {
+	rules, err := o.generateAlignRules()
+	if err != nil {
+		return 0, err
+	}
+	for _, rule := range rules {
+		ok, err := rule.match(name)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return rule.size, nil
+		}
+	}
+	return o.align, nil
+}
+
+// alignedSave wraps save so that the data of the stored files written
+// by save starts at the offset of the alignment.
+func (o *cmdParams) alignedSave(save func(io.Writer) error) func(io.Writer) error {
+	return func(w io.Writer) error {
+		if _, err := o.generateAlignRules(); err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		if err := save(buf); err != nil {
+			return err
+		}
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			return err
+		}
+
+		cw := &countWriter{w: w}
+		zw := zip.NewWriter(cw)
+		if err := zw.SetComment(zr.Comment); err != nil {
+			return err
+		}
+		for _, zf := range zr.File {
+			if err := zw.Flush(); err != nil {
+				return err
+			}
+			if err := o.copyAligned(zw, zf, cw.count); err != nil {
+				return fmt.Errorf("%s: %v", zf.Name, err)
+			}
+		}
+		return zw.Close()
+	}
+}
+
+// copyAligned copies zf at offset with the padding in the local header.
+// The central directory keeps the original extra field.
+func (o *cmdParams) copyAligned(zw *zip.Writer, zf *zip.File, offset int64) error {
+	extra := removeExtraField(zf.Extra, alignExtraID)
+	size, err := o.alignment(zf.Name)
+	if err != nil {
+		return err
+	}
+
+	if zf.Method == zip.Store && size > 1 {
+		dataOffset := offset + localHeaderLen + int64(len(zf.Name)+len(extra)+alignExtraLen)
+		padding := int((int64(size) - dataOffset%int64(size)) % int64(size))
+
+		field := make([]byte, alignExtraLen+padding)
+		binary.LittleEndian.PutUint16(field[0:], alignExtraID)
+		binary.LittleEndian.PutUint16(field[2:], uint16(2+padding))
+		binary.LittleEndian.PutUint16(field[4:], uint16(size))
+		zf.Extra = append(append([]byte{}, extra...), field...)
+	} else {
+		zf.Extra = extra
+	}
+
+	if err := zw.CopyFile(zf); err != nil {
+		return err
+	}
+	zf.Extra = extra
+	return nil
+}
+
+type countWriter struct {
+	w     io.Writer
+	count int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+// helperCreateAPK creates an archive whose stored files are not aligned.
+func helperCreateAPK(t *testing.T, filename string) {
+	t.Helper()
+
+	helperCreateArchive(t, filename, []testEntry{
+		{name: "AndroidManifest.xml", body: "<manifest/>"},
+		{name: "a.arsc", body: "resources", stored: true},
+		{name: "res/raw/b.bin", body: "raw data!", stored: true},
+		{name: "lib/arm64-v8a/libx.so", body: "native library", stored: true},
+		{name: "classes.dex", body: "dex"},
+	})
+}
+
+func helperAlignCheck(t *testing.T, filename string, alignments map[string]int64) {
+	t.Helper()
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		r, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", zf.Name, err)
+		}
+
+		size, ok := alignments[zf.Name]
+		if !ok {
+			continue
+		}
+		offset, err := zf.DataOffset()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if offset%size != 0 {
+			t.Fatalf("%s: data offset=%d, want aligned to %d", zf.Name, offset, size)
+		}
+	}
+}
+
+func TestAlignSave(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "app.apk")
+	helperCreateAPK(t, filename)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"rm", "--overwrite", "--align", "4", "--align-pattern", "**/*.so=4096", "--filter", "classes.dex", "--show-progress=false", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("error output=%q", stderr.String())
+	}
+
+	helperRmCheckFileContents(t, filename, []string{
+		"AndroidManifest.xml",
+		"a.arsc",
+		"res/raw/b.bin",
+		"lib/arm64-v8a/libx.so",
+	})
+	helperAlignCheck(t, filename, map[string]int64{
+		"a.arsc":                4,
+		"res/raw/b.bin":         4,
+		"lib/arm64-v8a/libx.so": 4096,
+	})
+}
+
+func TestAlignCmd(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "app.apk")
+	helperCreateAPK(t, filename)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout []string
+		stderr string
+	}{
+		{
+			name: "check_misaligned",
+			args: []string{"align", "--check"},
+			stdout: []string{
+				filename + ": a.arsc: data offset 119 is not aligned to 4",
+				filename + ": res/raw/b.bin: data offset 187 is not aligned to 4",
+				filename + ": lib/arm64-v8a/libx.so: data offset 263 is not aligned to 4",
+			},
+		},
+		{
+			name: "align",
+			args: []string{"align", "--overwrite", "--align-pattern", "lib/**=4096", "--show-progress=false"},
+		},
+		{
+			name: "check_aligned",
+			args: []string{"align", "--check", "--align-pattern", "lib/**=4096"},
+		},
+		{
+			name:   "invalid_alignment",
+			args:   []string{"align", "--check", "--align", "3"},
+			stderr: "alignment must be a power of two up to 32768\n",
+		},
+		{
+			name:   "invalid_pattern",
+			args:   []string{"align", "--check", "--align-pattern", "lib/**"},
+			stderr: "invalid alignment \"lib/**\" (PATTERN=N)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+		cmd.SetArgs(append(tt.args, filename))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := ""
+		if len(tt.stdout) != 0 {
+			want = strings.Join(tt.stdout, "\n") + "\n"
+		}
+		if stdout.String() != want {
+			t.Fatalf("%s: output=%q, want %q", tt.name, stdout.String(), want)
+		}
+		if stderr.String() != tt.stderr {
+			t.Fatalf("%s: error output=%q, want %q", tt.name, stderr.String(), tt.stderr)
+		}
+	}
+
+	helperAlignCheck(t, filename, map[string]int64{
+		"a.arsc":                4,
+		"res/raw/b.bin":         4,
+		"lib/arm64-v8a/libx.so": 4096,
+	})
+}
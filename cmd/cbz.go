@@ -0,0 +1,355 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+const (
+	cbzProfileName   = "cbz"
+	comicInfoName    = "ComicInfo.xml"
+	renumberTemp     = ".renumber~"
+	defaultPageWidth = 4
+)
+
+var (
+	pageExtensions = []string{
+		".jpg", ".jpeg", ".png", ".gif", ".webp", ".bmp", ".avif", ".jxl",
+	}
+	pageCountPattern    = regexp.MustCompile(`<PageCount>\s*(\d*)\s*</PageCount>`)
+	comicInfoEndPattern = regexp.MustCompile(`</ComicInfo>`)
+)
+
+// cbzProfile reports the page count of ComicInfo.xml which
+// does not match the pages after edits.
+var cbzProfile = &archiveProfile{
+	name:   cbzProfileName,
+	detect: isCBZ,
+	fixup:  (*cmdParams).fixupCBZ,
+}
+
+func isCBZ(zu *zip.Updater) bool {
+	return findHeader(zu, comicInfoName) != nil
+}
+
+func (o *cmdParams) fixupCBZ(zu *zip.Updater, changes *entryChanges, warn func(string, ...interface{})) error {
+	data, err := readUpdaterEntry(zu, comicInfoName)
+	if err != nil {
+		return err
+	}
+
+	if problem := pageCountProblem(data, countPages(updaterNames(zu))); len(problem) != 0 {
+		warn("%s (use cbz --page-count)", problem)
+	}
+	return nil
+}
+
+func newCbzCmd(params *cmdParams) *cobra.Command {
+	cbzcmd := &cbz{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "cbz [filepath...]",
+		Short: "Check or tidy comic archives",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cbzcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&cbzcmd.renumber, "renumber", false, "rename pages to sequential numbers in natural order")
+	cmd.Flags().IntVar(&cbzcmd.width, "digits", defaultPageWidth, "minimum digits of renumbered pages")
+	cmd.Flags().BoolVar(&cbzcmd.removeJunk, "remove-junk", false, "remove OS junk files (__MACOSX/, .DS_Store, Thumbs.db, ...)")
+	cmd.Flags().BoolVar(&cbzcmd.pageCount, "page-count", false, "write the page count to ComicInfo.xml (created if missing)")
+	cbzcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+// cbz reports the problems of comic archives without edit flags.
+type cbz struct {
+	*baseCmd
+	pexe       *toolParallelCmd
+	renumber   bool
+	width      int
+	removeJunk bool
+	pageCount  bool
+}
+
+func (o *cbz) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if !o.renumber && !o.removeJunk && !o.pageCount {
+		o.check(paths)
+		return
+	}
+	if o.width < 1 {
+		fmt.Fprintln(o.stderr, "digits must be positive")
+		return
+	}
+
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *cbz) execute(filepath string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		isModified := false
+		if o.removeJunk {
+			removed, err := removeEntries(zu, andFilter(filter, junkFilter))
+			if err != nil {
+				return false, err
+			}
+			isModified = len(removed) != 0
+		}
+		if o.renumber {
+			ok, err := o.renumberPages(zu, filter)
+			if err != nil {
+				return false, err
+			}
+			isModified = isModified || ok
+		}
+		if o.pageCount {
+			ok, err := writePageCount(zu, countPages(updaterNames(zu)))
+			if err != nil {
+				return false, err
+			}
+			isModified = isModified || ok
+		}
+		return isModified, nil
+	})
+}
+
+// renumberPages renames the pages of each directory to "0001.jpg" and so on
+// in natural order, and then sorts the entries in the same order.
+func (o *cbz) renumberPages(zu *zip.Updater, filter pathFilter) (bool, error) {
+	dirs := make(map[string][]string)
+	for _, header := range zu.Files() {
+		ok, err := filter(header)
+		if err != nil {
+			return false, err
+		}
+		if ok && isPageName(header.Name) {
+			dir := path.Dir(header.Name)
+			dirs[dir] = append(dirs[dir], header.Name)
+		}
+	}
+
+	renamed := make(map[string]string)
+	for _, pages := range dirs {
+		sort.SliceStable(pages, func(i, j int) bool {
+			return naturalLess(pages[i], pages[j])
+		})
+		for i, name := range pages {
+			dir, base := path.Split(name)
+			newname := fmt.Sprintf("%s%0*d%s", dir, o.width, i+1, strings.ToLower(path.Ext(base)))
+			if newname != name {
+				renamed[name] = newname
+			}
+		}
+	}
+	if len(renamed) == 0 {
+		return false, nil
+	}
+
+	// fail before any rename when a new name is used by an entry which keeps its name
+	for _, newname := range renamed {
+		if _, ok := renamed[newname]; !ok && findHeader(zu, newname) != nil {
+			return false, fmt.Errorf("%s: already exists", newname)
+		}
+		if findHeader(zu, newname+renumberTemp) != nil {
+			return false, fmt.Errorf("%s: already exists", newname+renumberTemp)
+		}
+	}
+
+	// rename through temporary names as the new names may be used by other pages
+	for oldname, newname := range renamed {
+		if err := zu.Rename(oldname, newname+renumberTemp); err != nil {
+			return false, fmt.Errorf("%s: %v", oldname, err)
+		}
+	}
+	for _, newname := range renamed {
+		if err := zu.Rename(newname+renumberTemp, newname); err != nil {
+			return false, fmt.Errorf("%s: %v", newname, err)
+		}
+	}
+
+	return true, zu.Sort(func(names []string) []string {
+		sorted := append([]string{}, names...)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return naturalLess(sorted[i], sorted[j])
+		})
+		return sorted
+	})
+}
+
+// writePageCount sets pages to PageCount of ComicInfo.xml.
+// The document is edited as text to keep the other elements as they are.
+func writePageCount(zu *zip.Updater, pages int) (bool, error) {
+	count := fmt.Sprintf("<PageCount>%d</PageCount>", pages)
+	if findHeader(zu, comicInfoName) == nil {
+		data := "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<ComicInfo>\n  " + count + "\n</ComicInfo>\n"
+		return true, writeUpdaterEntry(zu, comicInfoName, []byte(data))
+	}
+
+	data, err := readUpdaterEntry(zu, comicInfoName)
+	if err != nil {
+		return false, err
+	}
+	if n, ok := comicPageCount(data); ok && n == pages {
+		return false, nil
+	}
+
+	var result []byte
+	switch {
+	case pageCountPattern.Match(data):
+		result = pageCountPattern.ReplaceAll(data, []byte(count))
+	case comicInfoEndPattern.Match(data):
+		loc := comicInfoEndPattern.FindIndex(data)
+		result = append(append(append([]byte{}, data[:loc[0]]...), "  "+count+"\n"...), data[loc[0]:]...)
+	default:
+		return false, fmt.Errorf("%s: ComicInfo element is not found", comicInfoName)
+	}
+	return true, writeUpdaterEntry(zu, comicInfoName, result)
+}
+
+func (o *cbz) check(paths []string) {
+	if err := validateStdinPaths(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	for _, filepath := range paths {
+		problems, err := o.problems(filepath)
+		if err != nil {
+			fmt.Fprintln(o.stderr, err)
+			return
+		}
+		for _, problem := range problems {
+			fmt.Fprintf(o.stdout, "%s: %s\n", filepath, problem)
+		}
+	}
+}
+
+// problems returns the junk files and the wrong page count of filepath.
+func (o *cbz) problems(filepath string) ([]string, error) {
+	zr, closer, err := o.openZipReader(parseArchivePath(filepath))
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	problems := make([]string, 0)
+	names := make([]string, 0, len(zr.File))
+	var comicInfo *zip.File
+	for _, zf := range zr.File {
+		names = append(names, zf.Name)
+		if isJunkName(zf.Name) {
+			problems = append(problems, fmt.Sprintf("%s is junk", zf.Name))
+		}
+		if zf.Name == comicInfoName {
+			comicInfo = zf
+		}
+	}
+	if comicInfo == nil {
+		return problems, nil
+	}
+
+	r, err := comicInfo.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if problem := pageCountProblem(data, countPages(names)); len(problem) != 0 {
+		problems = append(problems, problem)
+	}
+	return problems, nil
+}
+
+// pageCountProblem describes PageCount of ComicInfo.xml data which is not pages.
+func pageCountProblem(data []byte, pages int) string {
+	n, ok := comicPageCount(data)
+	if !ok {
+		return fmt.Sprintf("%s has no PageCount", comicInfoName)
+	}
+	if n != pages {
+		return fmt.Sprintf("%s has PageCount %d, but the archive has %d pages", comicInfoName, n, pages)
+	}
+	return ""
+}
+
+func comicPageCount(data []byte) (int, bool) {
+	m := pageCountPattern.FindSubmatch(data)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(m[1]))
+	return n, err == nil
+}
+
+func countPages(names []string) int {
+	pages := 0
+	for _, name := range names {
+		if isPageName(name) {
+			pages++
+		}
+	}
+	return pages
+}
+
+// isPageName reports whether name is an image which is not junk.
+func isPageName(name string) bool {
+	if isJunkName(name) {
+		return false
+	}
+	ext := strings.ToLower(path.Ext(name))
+	for _, pageExt := range pageExtensions {
+		if ext == pageExt {
+			return true
+		}
+	}
+	return false
+}
+
+func updaterNames(zu *zip.Updater) []string {
+	headers := zu.Files()
+	names := make([]string, len(headers))
+	for i, header := range headers {
+		names[i] = header.Name
+	}
+	return names
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+const testComicInfo = `<?xml version="1.0" encoding="utf-8"?>
+<ComicInfo>
+  <Series>Example</Series>
+  <PageCount>4</PageCount>
+</ComicInfo>
+`
+
+func helperCreateCBZ(t *testing.T, filename string, comicInfo bool) {
+	t.Helper()
+
+	entries := []testEntry{
+		{name: "page10.JPG", body: "p10"},
+		{name: "page2.jpg", body: "p2"},
+		{name: "page1.jpg", body: "p1"},
+		{name: "__MACOSX/._page1.jpg", body: "resource fork"},
+		{name: ".DS_Store", body: "finder"},
+		{name: "Thumbs.db", body: "thumbnails"},
+		{name: "notes.txt", body: "notes"},
+	}
+	if comicInfo {
+		entries = append(entries, testEntry{name: comicInfoName, body: testComicInfo})
+	}
+	helperCreateArchive(t, filename, entries)
+}
+
+func TestIsJunkName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"__MACOSX/", true},
+		{"__MACOSX/dir/._a.jpg", true},
+		{"dir/.DS_Store", true},
+		{"dir/._a.jpg", true},
+		{"THUMBS.DB", true},
+		{"Desktop.ini", true},
+		{"dir/a.jpg", false},
+		{"MACOSX/a.jpg", false},
+		{"a.DS_Store", false},
+	}
+
+	for _, tt := range tests {
+		if got := isJunkName(tt.name); got != tt.want {
+			t.Fatalf("isJunkName(%q)=%v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCbzCmd(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "comic.cbz")
+	helperCreateCBZ(t, filename, true)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout []string
+		stderr string
+	}{
+		{
+			name: "check",
+			args: []string{"cbz"},
+			stdout: []string{
+				filename + ": __MACOSX/._page1.jpg is junk",
+				filename + ": .DS_Store is junk",
+				filename + ": Thumbs.db is junk",
+				filename + ": ComicInfo.xml has PageCount 4, but the archive has 3 pages",
+			},
+		},
+		{
+			name: "tidy",
+			args: []string{"cbz", "--overwrite", "--remove-junk", "--renumber", "--page-count", "--show-progress=false"},
+		},
+		{
+			name: "check_tidy",
+			args: []string{"cbz"},
+		},
+		{
+			name:   "rm_page",
+			args:   []string{"rm", "--overwrite", "--filter", "0002.jpg", "--show-progress=false"},
+			stderr: "warning: cbz: ComicInfo.xml has PageCount 3, but the archive has 2 pages (use cbz --page-count)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+		cmd.SetArgs(append(tt.args, filename))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		want := ""
+		if len(tt.stdout) != 0 {
+			want = strings.Join(tt.stdout, "\n") + "\n"
+		}
+		if stdout.String() != want {
+			t.Fatalf("%s: output=%q, want %q", tt.name, stdout.String(), want)
+		}
+		if stderr.String() != tt.stderr {
+			t.Fatalf("%s: error output=%q, want %q", tt.name, stderr.String(), tt.stderr)
+		}
+	}
+
+	helperRmCheckFileContents(t, filename, []string{
+		"0001.jpg",
+		"0003.jpg",
+		"ComicInfo.xml",
+		"notes.txt",
+	})
+	if got := helperReadEntry(t, filename, "0003.jpg"); got != "p10" {
+		t.Fatalf("0003.jpg=%q, want %q", got, "p10")
+	}
+	comicInfo := strings.Replace(testComicInfo, "<PageCount>4<", "<PageCount>3<", 1)
+	if got := helperReadEntry(t, filename, comicInfoName); got != comicInfo {
+		t.Fatalf("ComicInfo.xml=%q, want %q", got, comicInfo)
+	}
+}
+
+func TestCbzCreateComicInfo(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "comic.cbz")
+	helperCreateCBZ(t, filename, false)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"cbz", "--overwrite", "--page-count", "--show-progress=false", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("error output=%q", stderr.String())
+	}
+
+	want := "<?xml version=\"1.0\" encoding=\"utf-8\"?>\n<ComicInfo>\n  <PageCount>3</PageCount>\n</ComicInfo>\n"
+	if got := helperReadEntry(t, filename, comicInfoName); got != want {
+		t.Fatalf("ComicInfo.xml=%q, want %q", got, want)
+	}
+}
+
+func TestCbzRenumberConflict(t *testing.T) {
+	data := helperArchiveData(t, []testEntry{
+		{name: "0001.jpg", body: "0001.jpg"},
+		{name: "b.jpg", body: "b.jpg"},
+	})
+
+	zu, err := zip.NewUpdater(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zu.Close()
+
+	// 0001.jpg is not renumbered, but b.jpg becomes 0001.jpg
+	filter := func(h *zip.FileHeader) (bool, error) {
+		return h.Name == "b.jpg", nil
+	}
+	o := &cbz{width: defaultPageWidth}
+	if _, err := o.renumberPages(zu, filter); err == nil || err.Error() != "0001.jpg: already exists" {
+		t.Fatalf("error=%v, want the conflict", err)
+	}
+	if names := strings.Join(updaterNames(zu), ","); names != "0001.jpg,b.jpg" {
+		t.Fatalf("names=%q, want unchanged", names)
+	}
+}
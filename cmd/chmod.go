@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newChmodCmd(params *cmdParams) *cobra.Command {
+	chmodcmd := &chmod{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "chmod [mode] [filepath...]",
+		Short: "Change permissions of files",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("requires mode")
+			}
+			return params.requireInputs(cmd, args[1:])
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			chmodcmd.run(cmd, args)
+		},
+	}
+
+	chmodcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type chmod struct {
+	*baseCmd
+	pexe *toolParallelCmd
+}
+
+// modeChanger returns the new mode from the current mode.
+type modeChanger func(os.FileMode) os.FileMode
+
+func (o *chmod) run(cmd *cobra.Command, args []string) {
+	change, err := parseMode(args[0])
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	paths, err := o.expandFilePath(args[1:])
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath, change)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *chmod) execute(filepath string, change modeChanger) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		isModified := false
+		for _, header := range zu.Files() {
+			ok, err := filter(header)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+
+			// SetMode marks the creator as Unix
+			header.SetMode(change(header.Mode()))
+			isModified = true
+		}
+		return isModified, nil
+	})
+}
+
+// parseMode parses an octal mode like "755", or comma separated symbolic
+// modes like "u+x,go-w" consisting of [ugoa]*[+-=][rwxXst]*.
+func parseMode(s string) (modeChanger, error) {
+	if v, err := strconv.ParseUint(s, 8, 32); err == nil {
+		if v > 07777 {
+			return nil, fmt.Errorf("invalid mode %q", s)
+		}
+		perm := octalToFileMode(uint32(v))
+		return func(mode os.FileMode) os.FileMode {
+			return mode&os.ModeType | perm
+		}, nil
+	}
+
+	changes := make([]modeChanger, 0)
+	for _, clause := range strings.Split(s, ",") {
+		change, err := parseSymbolicMode(clause)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mode %q", s)
+		}
+		changes = append(changes, change)
+	}
+	return func(mode os.FileMode) os.FileMode {
+		for _, change := range changes {
+			mode = change(mode)
+		}
+		return mode
+	}, nil
+}
+
+func parseSymbolicMode(clause string) (modeChanger, error) {
+	i := strings.IndexAny(clause, "+-=")
+	if i < 0 {
+		return nil, fmt.Errorf("operator is not found")
+	}
+	who, op, perms := clause[:i], clause[i], clause[i+1:]
+
+	// mask of the permission bits of the users
+	var mask, special os.FileMode
+	if len(who) == 0 {
+		who = "a"
+	}
+	for _, c := range who {
+		switch c {
+		case 'u':
+			mask |= 0700
+			special |= os.ModeSetuid
+		case 'g':
+			mask |= 0070
+			special |= os.ModeSetgid
+		case 'o':
+			mask |= 0007
+		case 'a':
+			mask |= 0777
+			special |= os.ModeSetuid | os.ModeSetgid
+		default:
+			return nil, fmt.Errorf("invalid user %q", c)
+		}
+	}
+
+	for _, c := range perms {
+		if !strings.ContainsRune("rwxXst", c) {
+			return nil, fmt.Errorf("invalid permission %q", c)
+		}
+	}
+
+	return func(mode os.FileMode) os.FileMode {
+		var bits os.FileMode
+		for _, c := range perms {
+			switch c {
+			case 'r':
+				bits |= 0444 & mask
+			case 'w':
+				bits |= 0222 & mask
+			case 'x':
+				bits |= 0111 & mask
+			case 'X':
+				if mode.IsDir() || mode&0111 != 0 {
+					bits |= 0111 & mask
+				}
+			case 's':
+				bits |= special
+			case 't':
+				bits |= os.ModeSticky
+			}
+		}
+
+		switch op {
+		case '+':
+			return mode | bits
+		case '-':
+			return mode &^ bits
+		default:
+			return mode&^(mask|special) | bits
+		}
+	}, nil
+}
+
+func octalToFileMode(v uint32) os.FileMode {
+	mode := os.FileMode(v & 0777)
+	if v&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if v&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if v&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	return mode
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestParseMode(t *testing.T) {
+	tests := []struct {
+		mode string
+		from os.FileMode
+		want os.FileMode
+	}{
+		{"755", 0666, 0755},
+		{"0644", os.ModeDir | 0777, os.ModeDir | 0644},
+		{"4755", 0644, os.ModeSetuid | 0755},
+		{"u+x", 0644, 0744},
+		{"+x", 0644, 0755},
+		{"a-w", 0666, 0444},
+		{"u+x,go-w", 0666, 0744},
+		{"go=r", 0777, 0744},
+		{"a+X", 0644, 0644},
+		{"a+X", os.ModeDir | 0644, os.ModeDir | 0755},
+		{"u+s,+t", 0755, os.ModeSetuid | os.ModeSticky | 0755},
+	}
+
+	for _, tt := range tests {
+		change, err := parseMode(tt.mode)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.mode, err)
+		}
+		if got := change(tt.from); got != tt.want {
+			t.Fatalf("%s: mode of %v=%v, want %v", tt.mode, tt.from, got, tt.want)
+		}
+	}
+
+	for _, mode := range []string{"", "888", "77777", "u", "z+x", "u+q"} {
+		if _, err := parseMode(mode); err == nil {
+			t.Fatalf("%q: error was not reported", mode)
+		}
+	}
+}
+
+func TestChmodExecuteOverwrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		modes []string
+	}{
+		{
+			name: "octal",
+			args: []string{
+				"chmod",
+				"755",
+				"--overwrite",
+				"--filter",
+				"text1.txt",
+				"--show-progress=false",
+			},
+			modes: []string{
+				"drwxrwxrwx",
+				"-rw-rw-rw-",
+				"-rw-rw-rw-",
+				"-rwxr-xr-x",
+			},
+		},
+		{
+			name: "symbolic",
+			args: []string{
+				"chmod",
+				"u+x,go-w",
+				"--overwrite",
+				"--show-progress=false",
+			},
+			modes: []string{
+				"drwxr-xr-x",
+				"-rwxr--r--",
+				"-rwxr--r--",
+				"-rwxr--r--",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			args := append(append([]string{}, tt.args[:2]...), tmpname)
+			helperExecuteCommand(t, append(args, tt.args[2:]...))
+
+			stdout := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, new(bytes.Buffer))
+			cmd.SetArgs([]string{"ls", "-l", tmpname})
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+			if len(lines) != len(tt.modes) {
+				t.Fatalf("ls output=%q", stdout.String())
+			}
+			for i, line := range lines {
+				if !strings.HasPrefix(line, tt.modes[i]+" ") {
+					t.Fatalf("ls output=%q, want mode %s", line, tt.modes[i])
+				}
+			}
+
+			zr, err := zip.OpenReader(tmpname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer zr.Close()
+			for _, zf := range zr.File {
+				if tt.name == "octal" && zf.Name != "text1.txt" {
+					continue
+				}
+				if creator := zf.CreatorVersion >> 8; creator != 3 {
+					t.Fatalf("%s: creator=%d, want unix", zf.Name, creator)
+				}
+			}
+		})
+	}
+}
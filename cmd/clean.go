@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+// junkPatterns match the files which operating systems leave in archives.
+// They are matched case-insensitively against the name without the trailing slash.
+var junkPatterns = []string{
+	"**/__MACOSX",
+	"**/__MACOSX/**",
+	"**/.DS_Store",
+	"**/._*",
+	"**/Thumbs.db",
+	"**/desktop.ini",
+}
+
+func newCleanCmd(params *cmdParams) *cobra.Command {
+	cleancmd := &clean{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "clean [filepath...]",
+		Short: "Remove OS junk files",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			cleancmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&cleancmd.patterns, "pattern", nil, "additional junk filename pattern, matched at any depth without a slash (support wildcard, repeatable)")
+	cmd.Flags().StringVar(&cleancmd.patternsFrom, "patterns-from", "", "read additional junk patterns from file")
+	cmd.Flags().BoolVar(&cleancmd.noDefaults, "no-defaults", false, "do not use the built-in junk patterns")
+	cmd.Flags().BoolVar(&cleancmd.dryRun, "dry-run", false, "show junk files without updating archive")
+	cleancmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type clean struct {
+	*baseCmd
+	pexe         *toolParallelCmd
+	patterns     []string
+	patternsFrom string
+	noDefaults   bool
+	dryRun       bool
+	junk         pathFilter
+}
+
+func (o *clean) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.validate(); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	if o.dryRun && o.isStdoutOutput() {
+		fmt.Fprintln(o.stderr, "dry run cannot output archive to standard output")
+		return
+	}
+	if !o.dryRun {
+		if ok, err := o.validateOutputFlag(paths); !ok {
+			fmt.Fprintln(o.stderr, err.Error())
+			return
+		}
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *clean) validate() error {
+	patterns, err := o.loadPatterns(o.patterns, o.patternsFrom)
+	if err != nil {
+		return err
+	}
+	if o.noDefaults && len(patterns) == 0 {
+		return fmt.Errorf("--no-defaults requires --pattern or --patterns-from")
+	}
+
+	// a pattern without a slash matches the files of any directory like the built-in ones
+	for i, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			patterns[i] = "**/" + pattern
+		}
+	}
+
+	filters := make([]pathFilter, 0, 2)
+	if !o.noDefaults {
+		filters = append(filters, junkFilter)
+	}
+	if len(patterns) != 0 {
+		filter, err := o.anyGlobFilter(patterns)
+		if err != nil {
+			return err
+		}
+		filters = append(filters, filter)
+	}
+	o.junk = orFilter(filters...)
+	return nil
+}
+
+func (o *clean) execute(filepath string) error {
+	removed := make([]string, 0)
+	err := o.editNestedZipFile(filepath, func(zu *zip.Updater, filter pathFilter, nested string) (bool, error) {
+		counts := descendantCounts(zu)
+		dirs := make([]string, 0)
+		for _, header := range zu.Files() {
+			if !strings.HasSuffix(header.Name, "/") || counts[header.Name] == 0 {
+				continue
+			}
+			ok, err := filter(header)
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				dirs = append(dirs, header.Name)
+			}
+		}
+
+		names, err := removeEntries(zu, andFilter(filter, o.junk))
+		if err != nil {
+			return false, err
+		}
+		if len(names) == 0 {
+			return false, nil
+		}
+
+		emptied, err := removeEmptiedDirs(zu, dirs)
+		if err != nil {
+			return false, err
+		}
+
+		// the names of nested archives are reported with their address
+		for _, name := range append(names, emptied...) {
+			removed = append(removed, nested+name)
+		}
+		return !o.dryRun, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// write the report of an archive at once not to mix with the other jobs
+	report := new(strings.Builder)
+	for _, name := range removed {
+		fmt.Fprintf(report, "%s: %s\n", filepath, name)
+	}
+	// the report is not mixed with the archive written to standard output
+	w := o.stdout
+	if o.isStdoutOutput() {
+		w = o.stderr
+	}
+	fmt.Fprint(w, report.String())
+	return nil
+}
+
+// removeEmptiedDirs removes the directories of dirs which have no entries,
+// and returns the removed names. Parent directories are checked after
+// their children, as they may become empty by the removal.
+func removeEmptiedDirs(zu *zip.Updater, dirs []string) ([]string, error) {
+	sort.SliceStable(dirs, func(i, j int) bool {
+		return strings.Count(dirs[i], "/") > strings.Count(dirs[j], "/")
+	})
+
+	counts := descendantCounts(zu)
+	exists := make(map[string]bool)
+	for _, header := range zu.Files() {
+		exists[header.Name] = true
+	}
+
+	removed := make([]string, 0)
+	for _, dir := range dirs {
+		if !exists[dir] || counts[dir] != 0 {
+			continue
+		}
+		if err := zu.Remove(dir); err != nil {
+			return nil, err
+		}
+		for _, parent := range parentDirs(dir) {
+			counts[parent]--
+		}
+		removed = append(removed, dir)
+	}
+	return removed, nil
+}
+
+// descendantCounts returns the number of the entries under each directory of zu.
+func descendantCounts(zu *zip.Updater) map[string]int {
+	counts := make(map[string]int)
+	for _, header := range zu.Files() {
+		for _, dir := range parentDirs(header.Name) {
+			counts[dir]++
+		}
+	}
+	return counts
+}
+
+// parentDirs returns the directories which have name.
+func parentDirs(name string) []string {
+	elems := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	dirs := make([]string, 0, len(elems)-1)
+	for i := 1; i < len(elems); i++ {
+		dirs = append(dirs, strings.Join(elems[:i], "/")+"/")
+	}
+	return dirs
+}
+
+// junkFilter selects the files matching the built-in junk patterns.
+func junkFilter(h *zip.FileHeader) (bool, error) {
+	return isJunkName(h.Name), nil
+}
+
+// isJunkName reports whether name is a file which operating systems
+// leave in archives, like __MACOSX/, .DS_Store and Thumbs.db.
+func isJunkName(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "/"))
+	for _, pattern := range junkPatterns {
+		if ok, _ := doublestar.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+	return false
+}
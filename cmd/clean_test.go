@@ -0,0 +1,308 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func helperCreateJunkArchive(t *testing.T, filename string) {
+	t.Helper()
+
+	names := []string{
+		"docs/",
+		"docs/a.txt",
+		"docs/.DS_Store",
+		"docs/._a.txt",
+		"photos/",
+		"photos/Thumbs.db",
+		"photos/backup/",
+		"photos/backup/desktop.ini",
+		"empty/",
+		"__MACOSX/",
+		"__MACOSX/docs/",
+		"__MACOSX/docs/._a.txt",
+		"build.log",
+	}
+	var entries []testEntry
+	for _, name := range names {
+		e := testEntry{name: name}
+		if !strings.HasSuffix(name, "/") {
+			e.body = name
+		}
+		entries = append(entries, e)
+	}
+	helperCreateArchive(t, filename, entries)
+}
+
+func TestCleanExecuteOverwrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		report   []string
+		contents []string
+	}{
+		{
+			name: "defaults",
+			args: []string{"clean", "--overwrite", "--show-progress=false"},
+			report: []string{
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/Thumbs.db",
+				"photos/backup/desktop.ini",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"photos/backup/",
+				"photos/",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"empty/",
+				"build.log",
+			},
+		},
+		{
+			name: "extra_pattern",
+			args: []string{"clean", "--overwrite", "--pattern", "*.log", "--show-progress=false"},
+			report: []string{
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/Thumbs.db",
+				"photos/backup/desktop.ini",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"build.log",
+				"photos/backup/",
+				"photos/",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"empty/",
+			},
+		},
+		{
+			name: "no_defaults",
+			args: []string{"clean", "--overwrite", "--no-defaults", "--pattern", "photos/**/*.ini", "--show-progress=false"},
+			report: []string{
+				"photos/backup/desktop.ini",
+				"photos/backup/",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/",
+				"photos/Thumbs.db",
+				"empty/",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"build.log",
+			},
+		},
+		{
+			name: "bare_pattern_at_any_depth",
+			args: []string{"clean", "--overwrite", "--no-defaults", "--pattern", "*.ini", "--show-progress=false"},
+			report: []string{
+				"photos/backup/desktop.ini",
+				"photos/backup/",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/",
+				"photos/Thumbs.db",
+				"empty/",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"build.log",
+			},
+		},
+		{
+			name: "filter_keeps_dirs",
+			args: []string{"clean", "--overwrite", "--filter", "**/*.ini", "--show-progress=false"},
+			report: []string{
+				"photos/backup/desktop.ini",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/",
+				"photos/Thumbs.db",
+				"photos/backup/",
+				"empty/",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"build.log",
+			},
+		},
+		{
+			name: "dry_run",
+			args: []string{"clean", "--dry-run", "--filter", "photos/**", "--show-progress=false"},
+			report: []string{
+				"photos/Thumbs.db",
+				"photos/backup/desktop.ini",
+				"photos/backup/",
+			},
+			contents: []string{
+				"docs/",
+				"docs/a.txt",
+				"docs/.DS_Store",
+				"docs/._a.txt",
+				"photos/",
+				"photos/Thumbs.db",
+				"photos/backup/",
+				"photos/backup/desktop.ini",
+				"empty/",
+				"__MACOSX/",
+				"__MACOSX/docs/",
+				"__MACOSX/docs/._a.txt",
+				"build.log",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			filename := filepath.Join(tmpdir, "junk.zip")
+			helperCreateJunkArchive(t, filename)
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(append(tt.args, filename))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if stderr.Len() != 0 {
+				t.Fatalf("error output=%q", stderr.String())
+			}
+
+			want := ""
+			for _, name := range tt.report {
+				want += filename + ": " + name + "\n"
+			}
+			if stdout.String() != want {
+				t.Fatalf("output=%q, want %q", stdout.String(), want)
+			}
+
+			helperRmCheckFileContents(t, filename, tt.contents)
+		})
+	}
+}
+
+func TestCleanNested(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	inner := filepath.Join(tmpdir, "inner.zip")
+	helperCreateJunkArchive(t, inner)
+	data, err := ioutil.ReadFile(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(tmpdir, "outer.zip")
+	helperCreateArchive(t, filename, []testEntry{
+		{name: ".DS_Store", body: "finder"},
+		{name: "inner.zip", body: string(data)},
+	})
+
+	stdout := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, new(bytes.Buffer))
+	cmd.SetArgs([]string{"clean", "--dry-run", "--nested", "--filter", "**/Thumbs.db", "--show-progress=false", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := filename + ": inner.zip!/photos/Thumbs.db\n"
+	if stdout.String() != want {
+		t.Fatalf("output=%q, want %q", stdout.String(), want)
+	}
+}
+
+func TestCleanValidate(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"clean", "--overwrite", "--no-defaults", "../testcase/test.zip"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "--no-defaults requires --pattern or --patterns-from\n"
+	if stderr.String() != want {
+		t.Fatalf("error output=%q, want %q", stderr.String(), want)
+	}
+}
+
+func TestCleanStdout(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "test.zip")
+	helperCreateArchive(t, filename, []testEntry{
+		{name: "a.txt", body: "a"},
+		{name: ".DS_Store", body: "finder"},
+	})
+
+	// the report goes to the error output not to break the archive
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"clean", "--out", "-", "--show-progress=false", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filename + ": .DS_Store\n"; stderr.String() != want {
+		t.Fatalf("error output=%q, want %q", stderr.String(), want)
+	}
+	outname := filepath.Join(tmpdir, "out.zip")
+	if err := ioutil.WriteFile(outname, stdout.Bytes(), 0666); err != nil {
+		t.Fatal(err)
+	}
+	helperRmCheckFileContents(t, outname, []string{"a.txt"})
+
+	stdout.Reset()
+	stderr.Reset()
+	cmd = newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"clean", "--dry-run", "--out", "-", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "dry run cannot output archive to standard output\n"; stderr.String() != want {
+		t.Fatalf("error output=%q, want %q", stderr.String(), want)
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("output=%q, want nothing", stdout.String())
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+// maxCommentLen is the maximum length of archive and entry comments.
+const maxCommentLen = 1<<16 - 1
+
+func newCommentCmd(params *cmdParams) *cobra.Command {
+	commentcmd := &comment{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "comment [filepath...]",
+		Short: "Show or edit archive comment",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			commentcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&commentcmd.get, "get", false, "show comment (default)")
+	cmd.Flags().StringVar(&commentcmd.set, "set", "", "set comment")
+	cmd.Flags().StringVar(&commentcmd.setFile, "set-file", "", "set comment read from file")
+	cmd.Flags().BoolVar(&commentcmd.clear, "clear", false, "remove comment")
+	cmd.Flags().BoolVar(&commentcmd.entries, "entries", false, "target comments of entries selected by filters instead of archive")
+	commentcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type comment struct {
+	*baseCmd
+	pexe    *toolParallelCmd
+	get     bool
+	set     string
+	setFile string
+	clear   bool
+	entries bool
+}
+
+func (o *comment) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	isSet := cmd.Flags().Changed("set")
+	actions := 0
+	for _, ok := range []bool{o.get, isSet, len(o.setFile) != 0, o.clear} {
+		if ok {
+			actions++
+		}
+	}
+	if actions > 1 {
+		fmt.Fprintln(o.stderr, "only one of --get, --set, --set-file and --clear can be used")
+		return
+	}
+	if actions == 0 || o.get {
+		o.show(paths)
+		return
+	}
+
+	text := o.set
+	if len(o.setFile) != 0 {
+		data, err := ioutil.ReadFile(o.setFile)
+		if err != nil {
+			fmt.Fprintln(o.stderr, err)
+			return
+		}
+		text = string(data)
+	}
+	if len(text) > maxCommentLen {
+		fmt.Fprintf(o.stderr, "comment is too long (maximum %d bytes)\n", maxCommentLen)
+		return
+	}
+
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath, text)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *comment) execute(filepath, text string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		if !o.entries {
+			isModified := zu.Comment != text
+			zu.Comment = text
+			return isModified, nil
+		}
+
+		isModified := false
+		for _, header := range zu.Files() {
+			ok, err := filter(header)
+			if err != nil {
+				return false, err
+			}
+			if !ok || header.Comment == text {
+				continue
+			}
+
+			header.Comment = text
+			isModified = true
+		}
+		return isModified, nil
+	})
+}
+
+func (o *comment) show(paths []string) {
+	if err := validateStdinPaths(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	for i, filepath := range paths {
+		lines, err := o.comments(filepath)
+		if err != nil {
+			fmt.Fprintln(o.stderr, err)
+			return
+		}
+
+		if len(paths) != 1 {
+			if len(lines) == 0 {
+				continue
+			}
+			fmt.Fprintf(o.stdout, "%s:\n", filepath)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(o.stdout, line)
+		}
+		if len(paths) != 1 && i != len(paths)-1 {
+			fmt.Fprintln(o.stdout)
+		}
+	}
+}
+
+// comments returns the archive comment, or "name: comment" of the
+// selected entries which have a comment.
+func (o *comment) comments(filepath string) ([]string, error) {
+	apath := parseArchivePath(filepath)
+
+	zr, closer, err := o.openZipReader(apath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	if !o.entries {
+		if len(zr.Comment) == 0 {
+			return nil, nil
+		}
+		return []string{zr.Comment}, nil
+	}
+
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return nil, err
+	}
+	filter = andFilter(apath.scopeFilter(), filter)
+
+	result := make([]string, 0)
+	for _, zf := range zr.File {
+		ok, err := filter(&zf.FileHeader)
+		if err != nil {
+			return nil, err
+		}
+		if ok && len(zf.Comment) != 0 {
+			result = append(result, fmt.Sprintf("%s: %s", zf.Name, zf.Comment))
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestCommentExecuteOverwrite(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	commentFile := filepath.Join(tmpdir, "comment.txt")
+	if err := ioutil.WriteFile(commentFile, []byte("build 42"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		archive string
+		entries map[string]string
+	}{
+		{
+			name: "set",
+			args: []string{
+				"comment",
+				"--overwrite",
+				"--set",
+				"release 1.0",
+				"--show-progress=false",
+			},
+			archive: "release 1.0",
+			entries: map[string]string{},
+		},
+		{
+			name: "set_file",
+			args: []string{
+				"comment",
+				"--overwrite",
+				"--set-file",
+				commentFile,
+				"--show-progress=false",
+			},
+			archive: "build 42",
+			entries: map[string]string{},
+		},
+		{
+			name: "set_entries",
+			args: []string{
+				"comment",
+				"--overwrite",
+				"--entries",
+				"--filter",
+				"dir/*.txt",
+				"--set",
+				"checked",
+				"--show-progress=false",
+			},
+			archive: "",
+			entries: map[string]string{
+				"dir/text1.txt": "checked",
+				"dir/text2.txt": "checked",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			helperExecuteCommand(t, append(tt.args, tmpname))
+			helperCommentCheck(t, tmpname, tt.archive, tt.entries)
+		})
+	}
+}
+
+func TestCommentClear(t *testing.T) {
+	tmpname, err := copyTempFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpname)
+
+	helperExecuteCommand(t, []string{"comment", "--overwrite", "--set", "temporary", "--show-progress=false", tmpname})
+	helperExecuteCommand(t, []string{"comment", "--overwrite", "--clear", "--show-progress=false", tmpname})
+	helperCommentCheck(t, tmpname, "", map[string]string{})
+}
+
+func TestCommentGet(t *testing.T) {
+	tmpname, err := copyTempFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpname)
+
+	helperExecuteCommand(t, []string{"comment", "--overwrite", "--set", "release 1.0", "--show-progress=false", tmpname})
+	helperExecuteCommand(t, []string{"comment", "--overwrite", "--entries", "--filter", "text1.txt", "--set", "top", "--show-progress=false", tmpname})
+
+	tests := []struct {
+		name   string
+		args   []string
+		output []string
+	}{
+		{
+			name:   "archive",
+			args:   []string{"comment", tmpname},
+			output: []string{"release 1.0"},
+		},
+		{
+			name:   "entries",
+			args:   []string{"comment", "--get", "--entries", tmpname},
+			output: []string{"text1.txt: top"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+
+			want := strings.Join(tt.output, "\n") + "\n"
+			if out := stdout.String(); out != want {
+				t.Fatalf("output=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func helperCommentCheck(t *testing.T, filename string, archive string, entries map[string]string) {
+	t.Helper()
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	if zr.Comment != archive {
+		t.Fatalf("archive comment=%q, want %q", zr.Comment, archive)
+	}
+	for _, zf := range zr.File {
+		if zf.Comment != entries[zf.Name] {
+			t.Fatalf("%s: comment=%q, want %q", zf.Name, zf.Comment, entries[zf.Name])
+		}
+	}
+}
@@ -14,13 +14,13 @@ import (
 func newConvertCmd(params *cmdParams) *cobra.Command {
 	convcmd := &convert{
 		baseCmd: &baseCmd{params},
-		pexe:    &toolParallelCmd{writer: params.stdout},
+		pexe:    &toolParallelCmd{params: params},
 	}
 
 	var cmd = &cobra.Command{
 		Use:   "convert [filepath...]",
 		Short: "Convert file contents",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  params.requireInputs,
 		Run: func(cmd *cobra.Command, args []string) {
 			convcmd.run(cmd, args)
 		},
@@ -38,7 +38,7 @@ type convert struct {
 }
 
 func (o *convert) run(cmd *cobra.Command, args []string) {
-	paths, err := expandFilePath(args)
+	paths, err := o.expandFilePath(args)
 	if err != nil {
 		fmt.Fprintln(o.stderr, err)
 		return
@@ -69,15 +69,10 @@ func (o *convert) run(cmd *cobra.Command, args []string) {
 }
 
 func (o *convert) execute(filepath string) error {
-	return o.editZipFile(filepath, func(zu *zip.Updater) (bool, error) {
-		filter, err := o.generatePathFilter()
-		if err != nil {
-			return false, err
-		}
-
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
 		isModified := false
 		for _, header := range zu.Files() {
-			ok, err := filter(header.Name)
+			ok, err := filter(header)
 			if err != nil {
 				return false, err
 			}
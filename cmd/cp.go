@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newCpCmd(params *cmdParams) *cobra.Command {
+	return newTransferCmd(params, false)
+}
+
+func newMvCmd(params *cmdParams) *cobra.Command {
+	return newTransferCmd(params, true)
+}
+
+const transferLong = `Both forms update the archives in place without --overwrite.
+Within an archive, --out writes the result to another file instead.`
+
+func newTransferCmd(params *cmdParams, move bool) *cobra.Command {
+	transfercmd := &transfer{
+		baseCmd: &baseCmd{params},
+		move:    move,
+	}
+
+	use, short := "cp [src-filepath] [dst-filepath] | cp [filepath] [from] [to]", "Copy files to another archive or within an archive"
+	if move {
+		use, short = "mv [src-filepath] [dst-filepath] | mv [filepath] [from] [to]", "Move files to another archive or within an archive"
+	}
+
+	var cmd = &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  short + ".\n\n" + transferLong,
+		Args:  cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			transfercmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&transfercmd.prefix, "prefix", "", "path prefix of entries in the destination archive")
+	return cmd
+}
+
+// transfer copies entries from an archive to another archive or within
+// an archive, and with move removes them from the source afterwards.
+type transfer struct {
+	*baseCmd
+	prefix string
+	move   bool
+}
+
+func (o *transfer) run(cmd *cobra.Command, args []string) {
+	if len(args) == 3 {
+		o.runLocal(args[0], args[1], args[2])
+		return
+	}
+
+	if err := o.validate(args[0], args[1]); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.execute(args[0], args[1]); err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+func (o *transfer) validate(src, dst string) error {
+	if len(o.outFilename) != 0 || len(o.outDir) != 0 {
+		return fmt.Errorf("the destination archive is updated, output file name cannot be used")
+	}
+	if err := o.validateProfileFlag(); err != nil {
+		return err
+	}
+	if strings.Contains(dst, nestedSeparator) || dst == stdioPath {
+		return fmt.Errorf("%s: destination must be an archive file", dst)
+	}
+	if o.move && parseArchivePath(src).file == stdioPath {
+		return fmt.Errorf("standard input cannot be moved from")
+	}
+
+	if st1, err := os.Stat(parseArchivePath(src).file); err == nil {
+		if st2, err := os.Stat(dst); err == nil && os.SameFile(st1, st2) {
+			return fmt.Errorf("source and destination are the same archive")
+		}
+	}
+
+	// both archives are updated in place
+	o.isOverwrite = true
+	return nil
+}
+
+func (o *transfer) execute(src, dst string) error {
+	apath := parseArchivePath(src)
+	zr, closer, err := o.openZipReader(apath)
+	if err != nil {
+		return err
+	}
+	defer func() { close(closer) }()
+
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return err
+	}
+	filter = andFilter(apath.scopeFilter(), filter)
+
+	prefix := strings.Trim(o.prefix, "/")
+	if len(prefix) != 0 {
+		prefix += "/"
+	}
+
+	entries := make([]*mergeEntry, 0)
+	moved := make(map[int]string)
+	for i, zf := range zr.File {
+		ok, err := filter(&zf.FileHeader)
+		if err != nil {
+			return err
+		}
+		if ok {
+			entries = append(entries, &mergeEntry{prefix + zf.Name, src, zf})
+			moved[i] = zf.Name
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s: no files matched", src)
+	}
+
+	if err := o.writeDestination(dst, entries); err != nil {
+		return err
+	}
+	// release the source before it is overwritten
+	closer.Close()
+	closer = nil
+
+	if !o.move {
+		return nil
+	}
+
+	// remove the moved entries only from the archive they were read from,
+	// which has the entries in the same order as zr
+	o.nested = false
+	return o.editZipFile(src, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		removed := make([]string, 0, len(moved))
+		for i, header := range zu.Files() {
+			if name, ok := moved[i]; ok && header.Name == name {
+				removed = append(removed, name)
+			}
+		}
+		for _, name := range removed {
+			if err := zu.Remove(name); err != nil {
+				return false, err
+			}
+		}
+		return len(removed) != 0, nil
+	})
+}
+
+// writeDestination adds entries to dst, replacing the entries of the same name.
+// dst is created when it does not exist.
+func (o *transfer) writeDestination(dst string, entries []*mergeEntry) error {
+	existing := make([]*zip.File, 0)
+	comment := ""
+
+	var inputs []io.Closer
+	if _, err := os.Stat(dst); err == nil {
+		file, err := o.openInput(dst)
+		if err != nil {
+			return err
+		}
+		defer close(file)
+		inputs = append(inputs, file)
+
+		zr, err := zip.NewReader(file, file.size)
+		if err != nil {
+			return err
+		}
+		existing = zr.File
+		comment = zr.Comment
+	}
+
+	incoming := make(map[string]*mergeEntry, len(entries))
+	for _, entry := range entries {
+		incoming[entry.name] = entry
+	}
+
+	return o.writeOutput(dst, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		for _, zf := range existing {
+			entry, ok := incoming[zf.Name]
+			if !ok {
+				if err := zw.CopyFile(zf); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// keep the existing directory, and replace the file in place
+			if !strings.HasSuffix(zf.Name, "/") {
+				if err := copyEntry(zw, entry.file, entry.name); err != nil {
+					return err
+				}
+			} else if err := zw.CopyFile(zf); err != nil {
+				return err
+			}
+			delete(incoming, zf.Name)
+		}
+
+		for _, entry := range entries {
+			if _, ok := incoming[entry.name]; !ok {
+				continue
+			}
+			if err := copyEntry(zw, entry.file, entry.name); err != nil {
+				return err
+			}
+		}
+		if err := zw.SetComment(comment); err != nil {
+			return err
+		}
+		return zw.Close()
+	}, inputs...)
+}
+
+func (o *transfer) runLocal(filepath, from, to string) {
+	// the archive is updated in place as the other form does
+	if len(o.outFilename) == 0 && len(o.outDir) == 0 {
+		o.isOverwrite = true
+	}
+	if ok, err := o.validateOutputFlag([]string{filepath}); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	err := o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		return true, o.relocate(zu, strings.TrimPrefix(from, "/"), strings.TrimPrefix(to, "/"))
+	})
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+// relocate copies or moves the entry from to to within zu.
+// A directory is relocated with all of its descendants, and to
+// ending with a slash or naming an existing directory receives from.
+func (o *transfer) relocate(zu *zip.Updater, from, to string) error {
+	names := make(map[string]string)
+
+	if header := findHeader(zu, from); header != nil && !strings.HasSuffix(from, "/") {
+		dest := to
+		if strings.HasSuffix(to, "/") || isDirectory(zu, to) {
+			dest = strings.TrimSuffix(to, "/") + "/" + path.Base(from)
+		}
+		names[from] = dest
+	} else {
+		dir := strings.TrimSuffix(from, "/") + "/"
+		dest := strings.TrimSuffix(to, "/") + "/"
+		if isDirectory(zu, dest) {
+			dest += path.Base(dir) + "/"
+		}
+		if strings.HasPrefix(dest, dir) {
+			return fmt.Errorf("%s: cannot %s a directory into itself", from, o.name())
+		}
+
+		for _, header := range zu.Files() {
+			if strings.HasPrefix(header.Name, dir) {
+				names[header.Name] = dest + strings.TrimPrefix(header.Name, dir)
+			}
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("%s: not found", from)
+	}
+
+	// keep the order of the entries
+	targets := make([]string, 0, len(names))
+	for _, header := range zu.Files() {
+		newname, ok := names[header.Name]
+		if !ok {
+			continue
+		}
+		if findHeader(zu, newname) != nil {
+			return fmt.Errorf("%s: already exists", newname)
+		}
+		targets = append(targets, header.Name)
+	}
+
+	for _, name := range targets {
+		var err error
+		if o.move {
+			err = zu.Rename(name, names[name])
+		} else {
+			err = duplicateEntry(zu, name, names[name])
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	for _, name := range targets {
+		if err := createParentDirs(zu, names[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *transfer) name() string {
+	if o.move {
+		return "move"
+	}
+	return "copy"
+}
+
+// isDirectory reports whether zu has the directory name explicitly or implicitly.
+func isDirectory(zu *zip.Updater, name string) bool {
+	dir := strings.TrimSuffix(name, "/") + "/"
+	for _, header := range zu.Files() {
+		if strings.HasPrefix(header.Name, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// duplicateEntry copies the contents and the metadata of from as to.
+func duplicateEntry(zu *zip.Updater, from, to string) error {
+	src := findHeader(zu, from)
+
+	w, err := zu.Create(to)
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	header := findHeader(zu, to)
+	header.Method = src.Method
+	header.Comment = src.Comment
+	header.CreatorVersion = src.CreatorVersion
+	header.ExternalAttrs = src.ExternalAttrs
+
+	if !strings.HasSuffix(to, "/") {
+		r, err := zu.Open(from)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		w, err := zu.Update(to)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			w.Close()
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		header = findHeader(zu, to)
+	}
+
+	// the sizes and the offset of to are not the same as from
+	header.Extra = removeExtraField(removeExtraField(src.Extra, zip64ExtraID), alignExtraID)
+	header.Modified = src.Modified
+	header.ModifiedDate, header.ModifiedTime = src.ModifiedDate, src.ModifiedTime
+	return nil
+}
+
+// createParentDirs adds the missing directory entries of the parents of name.
+func createParentDirs(zu *zip.Updater, name string) error {
+	parts := strings.Split(strings.TrimSuffix(name, "/"), "/")
+	for i := 1; i < len(parts); i++ {
+		dir := strings.Join(parts[:i], "/") + "/"
+		if findHeader(zu, dir) != nil {
+			continue
+		}
+
+		w, err := zu.Create(dir)
+		if err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		setModTime(findHeader(zu, dir), time.Now())
+	}
+	return nil
+}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestTransferExecute(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		dst    string
+		src    []string
+		result []string
+		bodies map[string]string
+	}{
+		{
+			name: "cp_new_archive",
+			args: []string{
+				"cp",
+				"--filter",
+				"dir/*.txt",
+				"--prefix",
+				"static/",
+			},
+			src: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+			result: []string{
+				"static/dir/text1.txt",
+				"static/dir/text2.txt",
+			},
+			bodies: map[string]string{
+				"static/dir/text1.txt": "test 1",
+			},
+		},
+		{
+			name: "mv_replace",
+			args: []string{
+				"mv",
+				"--filter",
+				"text1.txt",
+			},
+			dst: "../testcase/test2.zip",
+			src: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+			result: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+			bodies: map[string]string{
+				"dir/text1.txt": "test 3\r\ntest 2\r\ntest 1",
+				"text1.txt":     "hello world",
+			},
+		},
+		{
+			name: "mv_prefix",
+			args: []string{
+				"mv",
+				"--filter",
+				"dir/**",
+				"--prefix",
+				"static",
+			},
+			dst: "../testcase/test2.zip",
+			src: []string{
+				"dir/",
+				"text1.txt",
+			},
+			result: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"static/dir/text1.txt",
+				"static/dir/text2.txt",
+			},
+			bodies: map[string]string{
+				"static/dir/text1.txt": "test 1",
+				"text1.txt":            "hello3\r\nhello2\r\nhello1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			src := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "src.zip"))
+			dst := filepath.Join(tmpdir, "dst.zip")
+			if len(tt.dst) != 0 {
+				helperCopyFileTo(t, tt.dst, dst)
+			}
+
+			helperExecuteCommand(t, append(tt.args, src, dst))
+			helperRmCheckFileContents(t, src, tt.src)
+			helperRmCheckFileContents(t, dst, tt.result)
+			helperConvertCheckFileContents(t, dst, tt.bodies)
+		})
+	}
+}
+
+func TestTransferNoMatch(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "src.zip"))
+	dst := filepath.Join(tmpdir, "dst.zip")
+
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), new(bytes.Buffer), stderr)
+	cmd.SetArgs([]string{"mv", "--filter", "none", src, dst})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatal("no error output")
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatalf("destination was created: %v", err)
+	}
+	helperRmCheckFileContents(t, src, []string{
+		"dir/",
+		"dir/text1.txt",
+		"dir/text2.txt",
+		"text1.txt",
+	})
+}
+
+func TestTransferLocalExecuteOverwrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+		bodies   map[string]string
+		fail     bool
+	}{
+		{
+			name: "cp_file",
+			args: []string{"cp", "--overwrite", "text1.txt", "index.txt"},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"index.txt",
+			},
+			bodies: map[string]string{
+				"text1.txt": "hello world",
+				"index.txt": "hello world",
+			},
+		},
+		{
+			name: "cp_directory",
+			args: []string{"cp", "--overwrite", "dir", "copy"},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"copy/",
+				"copy/text1.txt",
+				"copy/text2.txt",
+			},
+			bodies: map[string]string{
+				"copy/text2.txt": "test 2",
+			},
+		},
+		{
+			name: "mv_file_into_directory",
+			args: []string{"mv", "--overwrite", "dir/text1.txt", "new/"},
+			contents: []string{
+				"dir/",
+				"new/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"new/",
+			},
+			bodies: map[string]string{
+				"new/text1.txt": "test 1",
+			},
+		},
+		{
+			name: "mv_directory",
+			args: []string{"mv", "--overwrite", "dir/", "a/b"},
+			contents: []string{
+				"a/b/",
+				"a/b/text1.txt",
+				"a/b/text2.txt",
+				"text1.txt",
+				"a/",
+			},
+			bodies: map[string]string{
+				"a/b/text1.txt": "test 1",
+			},
+		},
+		{
+			name: "mv_into_existing_directory",
+			args: []string{"mv", "--overwrite", "text1.txt", "dir"},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+			fail: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			args := append(append([]string{}, tt.args[:2]...), tmpname)
+			args = append(args, tt.args[2:]...)
+
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), new(bytes.Buffer), stderr)
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.fail && stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+			if tt.fail && stderr.Len() == 0 {
+				t.Fatal("conflict was not reported")
+			}
+
+			helperRmCheckFileContents(t, tmpname, tt.contents)
+			helperConvertCheckFileContents(t, tmpname, tt.bodies)
+		})
+	}
+}
+
+func TestTransferLocalKeepsMetadata(t *testing.T) {
+	tmpname, err := copyTempFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpname)
+
+	helperExecuteCommand(t, []string{"cp", "--overwrite", tmpname, "text1.txt", "index.txt"})
+
+	zr, err := zip.OpenReader(tmpname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	headers := make(map[string]*zip.FileHeader)
+	for _, zf := range zr.File {
+		headers[zf.Name] = &zf.FileHeader
+	}
+	src, dst := headers["text1.txt"], headers["index.txt"]
+	if dst == nil {
+		t.Fatal("index.txt is not found")
+	}
+	if dst.Method != src.Method {
+		t.Fatalf("method=%d, want %d", dst.Method, src.Method)
+	}
+	if !dst.ModTime().Equal(src.ModTime()) {
+		t.Fatalf("mtime=%v, want %v", dst.ModTime(), src.ModTime())
+	}
+	if dst.CRC32 != src.CRC32 {
+		t.Fatalf("crc32=%x, want %x", dst.CRC32, src.CRC32)
+	}
+}
+
+func TestTransferMoveNested(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := helperCopyFileTo(t, "../testcase/nested.zip", filepath.Join(tmpdir, "src.zip"))
+	dst := filepath.Join(tmpdir, "dst.zip")
+
+	helperExecuteCommand(t, []string{"mv", "--nested", "--filter", "text1.txt", src, dst})
+	helperRmCheckFileContents(t, src, []string{"inner.zip"})
+	helperRmCheckFileContents(t, dst, []string{"text1.txt"})
+
+	// the inner archive is not the source of the move
+	stdout := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, new(bytes.Buffer))
+	cmd.SetArgs([]string{"ls", src + "!/inner.zip!/"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	want := "dir/\ndir/text1.txt\ndir/text2.txt\ntext1.txt\n"
+	if out := stdout.String(); out != want {
+		t.Fatalf("inner contents=%q, want %q", out, want)
+	}
+}
+
+func TestTransferLocalDropsLayoutExtra(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// a.arsc has the padding of zipalign in the central directory too
+	filename := filepath.Join(tmpdir, "app.apk")
+	helperCreateArchive(t, filename, []testEntry{{
+		name:   "a.arsc",
+		body:   "resources",
+		stored: true,
+		extra:  []byte{0x35, 0xd9, 0x04, 0x00, 0x04, 0x00, 0x00, 0x00},
+	}})
+
+	helperExecuteCommand(t, []string{"cp", filename, "a.arsc", "b.arsc"})
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if zf.Name != "b.arsc" {
+			continue
+		}
+		for _, id := range []uint16{zip64ExtraID, alignExtraID} {
+			if len(removeExtraField(zf.Extra, id)) != len(zf.Extra) {
+				t.Fatalf("extra field %#04x is copied", id)
+			}
+		}
+		return
+	}
+	t.Fatal("b.arsc is not found")
+}
@@ -0,0 +1,393 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCmd(params *cmdParams) *cobra.Command {
+	diffcmd := &diff{
+		baseCmd: &baseCmd{params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "diff [filepath] [filepath|directory]",
+		Short: "Show differences between archives or an archive and a directory",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			diffcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&diffcmd.showText, "text", false, "show unified diff of modified text files")
+	cmd.Flags().IntVar(&diffcmd.context, "context", 3, "number of context lines of unified diff")
+	cmd.Flags().BoolVar(&diffcmd.json, "json", false, "output in JSON format")
+	return cmd
+}
+
+type diff struct {
+	*baseCmd
+	showText bool
+	context  int
+	json     bool
+}
+
+// entryInfo is a snapshot of a file compared by diff.
+type entryInfo struct {
+	name     string
+	isDir    bool
+	size     uint64
+	crc32    uint32
+	modified time.Time
+	mode     os.FileMode
+	method   uint16
+	comment  string
+	open     func() (io.ReadCloser, error)
+}
+
+func newEntryInfo(zf *zip.File) *entryInfo {
+	modified := headerModTime(&zf.FileHeader)
+	return &entryInfo{
+		name:     zf.Name,
+		isDir:    strings.HasSuffix(zf.Name, "/"),
+		size:     zf.UncompressedSize64,
+		crc32:    zf.CRC32,
+		modified: modified,
+		mode:     zf.Mode(),
+		method:   zf.Method,
+		comment:  zf.Comment,
+		open:     zf.Open,
+	}
+}
+
+type diffResult struct {
+	Name   string   `json:"name"`
+	Status string   `json:"status"`
+	Fields []string `json:"fields,omitempty"`
+	Diff   string   `json:"diff,omitempty"`
+}
+
+const (
+	diffAdded    = "added"
+	diffRemoved  = "removed"
+	diffModified = "modified"
+	diffMetadata = "metadata"
+	diffMissing  = "missing"
+	diffExtra    = "extra"
+)
+
+func (o *diff) run(cmd *cobra.Command, args []string) {
+	if err := validateStdinPaths(args); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	results, err := o.execute(args[0], args[1])
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.render(results); err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+func (o *diff) execute(path1, path2 string) ([]*diffResult, error) {
+	if st, err := os.Stat(path2); err == nil && st.IsDir() {
+		return o.executeDirectory(path1, path2)
+	}
+
+	zr1, closer1, err := o.openZipReader(parseArchivePath(path1))
+	if err != nil {
+		return nil, err
+	}
+	defer closer1.Close()
+
+	zr2, closer2, err := o.openZipReader(parseArchivePath(path2))
+	if err != nil {
+		return nil, err
+	}
+	defer closer2.Close()
+
+	entries1, err := o.archiveEntries(zr1)
+	if err != nil {
+		return nil, err
+	}
+	entries2, err := o.archiveEntries(zr2)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.compare(entries1, entries2, compareArchiveMetadata)
+}
+
+// executeDirectory compares the archive with the directory it was built from.
+// Entries only in the archive are extra, and files only in the directory are missing.
+func (o *diff) executeDirectory(filepath, dir string) ([]*diffResult, error) {
+	zr, closer, err := o.openZipReader(parseArchivePath(filepath))
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	entries1, err := o.archiveEntries(zr)
+	if err != nil {
+		return nil, err
+	}
+	entries2, err := o.directoryEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*entryInfo, 0, len(entries1))
+	names := make(map[string]bool)
+	for _, e := range entries1 {
+		if !e.isDir {
+			files = append(files, e)
+			names[e.name] = true
+		}
+	}
+
+	// checksum only the files in both, which are compared by the contents
+	for _, e := range entries2 {
+		if names[e.name] {
+			if e.crc32, err = fileCRC32(e.open); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	results, err := o.compare(files, entries2, func(e1, e2 *entryInfo) []string {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		switch result.Status {
+		case diffRemoved:
+			result.Status = diffExtra
+		case diffAdded:
+			result.Status = diffMissing
+		}
+	}
+	return results, nil
+}
+
+// directoryEntries returns the files under dir named like archive entries.
+func (o *baseCmd) directoryEntries(dir string) ([]*entryInfo, error) {
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entryInfo, 0)
+	err = path.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := path.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		e := &entryInfo{
+			name:     path.ToSlash(rel),
+			size:     uint64(info.Size()),
+			modified: info.ModTime(),
+			mode:     info.Mode(),
+			open: func() (io.ReadCloser, error) {
+				return os.Open(p)
+			},
+		}
+
+		ok, err := filter(&zip.FileHeader{
+			Name:               e.name,
+			UncompressedSize64: e.size,
+			Modified:           e.modified,
+		})
+		if err != nil {
+			return err
+		}
+		if ok {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func fileCRC32(open func() (io.ReadCloser, error)) (uint32, error) {
+	r, err := open()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	hash := crc32.NewIEEE()
+	if _, err := io.Copy(hash, r); err != nil {
+		return 0, err
+	}
+	return hash.Sum32(), nil
+}
+
+func (o *diff) archiveEntries(zr *zip.Reader) ([]*entryInfo, error) {
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entryInfo, 0, len(zr.File))
+	for _, zf := range zr.File {
+		ok, err := filter(&zf.FileHeader)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			entries = append(entries, newEntryInfo(zf))
+		}
+	}
+	return entries, nil
+}
+
+// compare reports the changes from entries1 to entries2.
+// metadata returns the names of the changed metadata fields.
+func (o *diff) compare(entries1, entries2 []*entryInfo, metadata func(e1, e2 *entryInfo) []string) ([]*diffResult, error) {
+	index2 := make(map[string]*entryInfo, len(entries2))
+	for _, e := range entries2 {
+		index2[e.name] = e
+	}
+	index1 := make(map[string]bool, len(entries1))
+
+	results := make([]*diffResult, 0)
+	for _, e1 := range entries1 {
+		index1[e1.name] = true
+
+		e2, ok := index2[e1.name]
+		if !ok {
+			results = append(results, &diffResult{Name: e1.name, Status: diffRemoved})
+			continue
+		}
+
+		if fields := contentChanges(e1, e2); len(fields) != 0 {
+			result := &diffResult{Name: e1.name, Status: diffModified, Fields: fields}
+			if o.showText {
+				text, err := o.textDiff(e1, e2)
+				if err != nil {
+					return nil, err
+				}
+				result.Diff = text
+			}
+			results = append(results, result)
+			continue
+		}
+
+		if fields := metadata(e1, e2); len(fields) != 0 {
+			results = append(results, &diffResult{Name: e1.name, Status: diffMetadata, Fields: fields})
+		}
+	}
+
+	for _, e2 := range entries2 {
+		if !index1[e2.name] {
+			results = append(results, &diffResult{Name: e2.name, Status: diffAdded})
+		}
+	}
+	return results, nil
+}
+
+func contentChanges(e1, e2 *entryInfo) []string {
+	fields := make([]string, 0)
+	if e1.size != e2.size {
+		fields = append(fields, "size")
+	}
+	if e1.crc32 != e2.crc32 {
+		fields = append(fields, "crc32")
+	}
+	return fields
+}
+
+func compareArchiveMetadata(e1, e2 *entryInfo) []string {
+	fields := make([]string, 0)
+	if !e1.modified.Equal(e2.modified) {
+		fields = append(fields, "mtime")
+	}
+	if e1.mode != e2.mode {
+		fields = append(fields, "mode")
+	}
+	if e1.method != e2.method {
+		fields = append(fields, "method")
+	}
+	if e1.comment != e2.comment {
+		fields = append(fields, "comment")
+	}
+	return fields
+}
+
+func (o *diff) textDiff(e1, e2 *entryInfo) (string, error) {
+	text1, ok, err := readText(e1)
+	if err != nil || !ok {
+		return "", err
+	}
+	text2, ok, err := readText(e2)
+	if err != nil || !ok {
+		return "", err
+	}
+	return unifiedDiff(splitLines(text1), splitLines(text2), "a/"+e1.name, "b/"+e2.name, o.context), nil
+}
+
+// readText returns the contents of e, and false if it is not a text file.
+func readText(e *entryInfo) (string, bool, error) {
+	r, err := e.open()
+	if err != nil {
+		return "", false, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", false, err
+	}
+	if !utf8.Valid(data) || strings.ContainsRune(string(data), 0) {
+		return "", false, nil
+	}
+	return string(data), true, nil
+}
+
+func (o *diff) render(results []*diffResult) error {
+	if o.json {
+		enc := json.NewEncoder(o.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+
+	for _, result := range results {
+		if result.Status == diffMetadata {
+			fmt.Fprintf(o.stdout, "%s: %s (%s)\n", result.Status, result.Name, strings.Join(result.Fields, ", "))
+		} else {
+			fmt.Fprintf(o.stdout, "%s: %s\n", result.Status, result.Name)
+		}
+		if len(result.Diff) != 0 {
+			fmt.Fprint(o.stdout, result.Diff)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		output []string
+	}{
+		{
+			name: "archives",
+			args: []string{
+				"diff",
+				"../testcase/test.zip",
+				"../testcase/test2.zip",
+			},
+			output: []string{
+				"modified: dir/text1.txt",
+				"metadata: dir/text2.txt (method)",
+				"modified: text1.txt",
+			},
+		},
+		{
+			name: "added_and_removed",
+			args: []string{
+				"diff",
+				"../testcase/test.zip",
+				"../testcase/nested.zip",
+				"--filter",
+				"*",
+			},
+			output: []string{
+				"removed: dir/",
+				"modified: text1.txt",
+				"added: inner.zip",
+			},
+		},
+		{
+			name: "text",
+			args: []string{
+				"diff",
+				"../testcase/test.zip",
+				"../testcase/nested.zip",
+				"--filter",
+				"text1.txt",
+				"--text",
+			},
+			output: []string{
+				"modified: text1.txt",
+				"--- a/text1.txt",
+				"+++ b/text1.txt",
+				"@@ -1,1 +1,1 @@",
+				"-hello world",
+				"+hello nested",
+			},
+		},
+		{
+			name: "nested",
+			args: []string{
+				"diff",
+				"../testcase/test.zip",
+				"../testcase/nested.zip!/inner.zip!/",
+			},
+			output: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+
+			want := ""
+			if len(tt.output) != 0 {
+				want = strings.Join(tt.output, "\n") + "\n"
+			}
+			if out := stdout.String(); out != want {
+				t.Fatalf("output=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"diff",
+		"--json",
+		"../testcase/test.zip",
+		"../testcase/test2.zip",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	var results []diffResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []diffResult{
+		{Name: "dir/text1.txt", Status: diffModified, Fields: []string{"size", "crc32"}},
+		{Name: "dir/text2.txt", Status: diffMetadata, Fields: []string{"method"}},
+		{Name: "text1.txt", Status: diffModified, Fields: []string{"size", "crc32"}},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("result count=%d, want %d", len(results), len(want))
+	}
+	for i, r := range results {
+		if r.Name != want[i].Name || r.Status != want[i].Status || strings.Join(r.Fields, ",") != strings.Join(want[i].Fields, ",") {
+			t.Fatalf("result=%+v, want %+v", r, want[i])
+		}
+	}
+}
+
+func TestDiffDirectoryRender(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	files := map[string]string{
+		"dir/text1.txt": "test 1",
+		"dir/text2.txt": "test X",
+		"new.txt":       "new file",
+	}
+	for name, body := range files {
+		filename := filepath.Join(tmpdir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"diff",
+		"../testcase/test.zip",
+		tmpdir,
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	want := strings.Join([]string{
+		"modified: dir/text2.txt",
+		"extra: text1.txt",
+		"missing: new.txt",
+	}, "\n") + "\n"
+	if out := stdout.String(); out != want {
+		t.Fatalf("output=%q, want %q", out, want)
+	}
+}
+
+func TestDiffDirectoryChecksum(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// the checksum of the empty entry is 0 like that of a file not checksummed
+	filename := filepath.Join(tmpdir, "test.zip")
+	helperCreateArchive(t, filename, []testEntry{{name: "text.txt"}})
+
+	dir := filepath.Join(tmpdir, "dir")
+	if err := os.Mkdir(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "text.txt"), []byte("text"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"diff", "--json", filename, dir})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	var results []diffResult
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || strings.Join(results[0].Fields, ",") != "size,crc32" {
+		t.Fatalf("results=%+v, want size and crc32 of text.txt", results)
+	}
+}
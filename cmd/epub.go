@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hidez8891/zip"
+)
+
+const (
+	epubMimetypeName      = "mimetype"
+	epubMimetype          = "application/epub+zip"
+	epubContainerName     = "META-INF/container.xml"
+	epubProfileName       = "epub"
+	epubRootfileMediaType = "application/oebps-package+xml"
+)
+
+// epubProfile keeps the OCF rules: mimetype is the first entry,
+// stored without compression and extra field.
+var epubProfile = &archiveProfile{
+	name:    epubProfileName,
+	leading: []string{epubMimetypeName},
+	stored:  []string{epubMimetypeName},
+	detect:  isEPUB,
+	fixup:   (*cmdParams).fixupEPUB,
+}
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Items []struct {
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+}
+
+func isEPUB(zu *zip.Updater) bool {
+	if findHeader(zu, epubMimetypeName) == nil {
+		return false
+	}
+	data, err := readUpdaterEntry(zu, epubMimetypeName)
+	return err == nil && strings.TrimSpace(string(data)) == epubMimetype
+}
+
+func (o *cmdParams) fixupEPUB(zu *zip.Updater, changes *entryChanges, warn func(string, ...interface{})) error {
+	if findHeader(zu, epubMimetypeName) == nil {
+		warn("%s is not found", epubMimetypeName)
+	} else if err := fixupEPUBMimetype(zu); err != nil {
+		return err
+	}
+
+	opfs, err := epubRootfiles(zu)
+	if err != nil {
+		warn("%v", err)
+		return nil
+	}
+
+	for _, opf := range opfs {
+		if findHeader(zu, opf) == nil {
+			warn("%s refers to missing %s", epubContainerName, opf)
+			continue
+		}
+
+		refs, err := epubManifestItems(zu, opf)
+		if err != nil {
+			warn("%s: %v", opf, err)
+			continue
+		}
+
+		lost := append([]string{}, changes.removed...)
+		for oldname := range changes.renamed {
+			lost = append(lost, oldname)
+		}
+		for _, name := range lost {
+			if refs[name] && findHeader(zu, name) == nil {
+				warn("%s is still referenced in %s", name, opf)
+			}
+		}
+	}
+	return nil
+}
+
+// fixupEPUBMimetype moves mimetype to the top, and rewrites it stored without extra field.
+func fixupEPUBMimetype(zu *zip.Updater) error {
+	headers := zu.Files()
+	if headers[0].Name != epubMimetypeName {
+		err := zu.Sort(func(names []string) []string {
+			sorted := []string{epubMimetypeName}
+			for _, name := range names {
+				if name != epubMimetypeName {
+					sorted = append(sorted, name)
+				}
+			}
+			return sorted
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	header := findHeader(zu, epubMimetypeName)
+	if header.Method == zip.Store && len(header.Extra) == 0 && header.Flags&zip.FlagDataDescriptor == 0 {
+		return nil
+	}
+
+	data, err := readUpdaterEntry(zu, epubMimetypeName)
+	if err != nil {
+		return err
+	}
+
+	// keep the MS-DOS time only not to add the extended timestamp
+	header.Method = zip.Store
+	header.Extra = nil
+	header.Modified = time.Time{}
+	header.Flags &^= zip.FlagDataDescriptor
+
+	return writeUpdaterEntry(zu, epubMimetypeName, data)
+}
+
+// epubRootfiles returns the package documents listed in the container.
+func epubRootfiles(zu *zip.Updater) ([]string, error) {
+	if findHeader(zu, epubContainerName) == nil {
+		return nil, fmt.Errorf("%s is not found", epubContainerName)
+	}
+	data, err := readUpdaterEntry(zu, epubContainerName)
+	if err != nil {
+		return nil, err
+	}
+
+	var container epubContainer
+	if err := xml.Unmarshal(data, &container); err != nil {
+		return nil, fmt.Errorf("%s: %v", epubContainerName, err)
+	}
+
+	opfs := make([]string, 0)
+	for _, rootfile := range container.Rootfiles {
+		if len(rootfile.MediaType) == 0 || rootfile.MediaType == epubRootfileMediaType {
+			opfs = append(opfs, rootfile.FullPath)
+		}
+	}
+	if len(opfs) == 0 {
+		return nil, fmt.Errorf("%s has no package document", epubContainerName)
+	}
+	return opfs, nil
+}
+
+// epubManifestItems returns the entry names of the manifest items of the package document.
+func epubManifestItems(zu *zip.Updater, opf string) (map[string]bool, error) {
+	data, err := readUpdaterEntry(zu, opf)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg epubPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]bool)
+	for _, item := range pkg.Items {
+		if name, ok := resolveHref(opf, item.Href); ok {
+			refs[name] = true
+		}
+	}
+	return refs, nil
+}
+
+// resolveHref returns the entry name which href in the document base refers to.
+func resolveHref(base, href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || u.IsAbs() || len(u.Path) == 0 {
+		return "", false
+	}
+	if strings.HasPrefix(u.Path, "/") {
+		return strings.TrimPrefix(path.Clean(u.Path), "/"), true
+	}
+	return path.Clean(path.Join(path.Dir(base), u.Path)), true
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hidez8891/zip"
+)
+
+const testEPUBContainer = `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles>
+    <rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/>
+  </rootfiles>
+</container>
+`
+
+const testEPUBPackage = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <manifest>
+    <item id="ch1" href="ch1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="ch2" href="text/ch2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+</package>
+`
+
+// helperCreateEPUB creates a broken EPUB whose mimetype is neither first nor stored.
+func helperCreateEPUB(t *testing.T, filename string) {
+	t.Helper()
+
+	now := time.Now()
+	helperCreateArchive(t, filename, []testEntry{
+		{name: "META-INF/container.xml", body: testEPUBContainer, modified: now},
+		{name: "mimetype", body: epubMimetype, modified: now},
+		{name: "OEBPS/content.opf", body: testEPUBPackage, modified: now},
+		{name: "OEBPS/ch1.xhtml", body: "<html/>", modified: now},
+		{name: "OEBPS/text/ch2.xhtml", body: "<html/>", modified: now},
+	})
+}
+
+func TestEPUBProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+		warnings []string
+	}{
+		{
+			name: "rm_referenced",
+			args: []string{"rm", "--overwrite", "--filter", "OEBPS/text/*", "--show-progress=false"},
+			contents: []string{
+				"mimetype",
+				"META-INF/container.xml",
+				"OEBPS/content.opf",
+				"OEBPS/ch1.xhtml",
+			},
+			warnings: []string{
+				"warning: epub: OEBPS/text/ch2.xhtml is still referenced in OEBPS/content.opf",
+			},
+		},
+		{
+			name: "rename_referenced",
+			args: []string{"rename", "--overwrite", "--from", "ch1", "--to", "chapter1", "--show-progress=false"},
+			contents: []string{
+				"mimetype",
+				"META-INF/container.xml",
+				"OEBPS/content.opf",
+				"OEBPS/chapter1.xhtml",
+				"OEBPS/text/ch2.xhtml",
+			},
+			warnings: []string{
+				"warning: epub: OEBPS/ch1.xhtml is still referenced in OEBPS/content.opf",
+			},
+		},
+		{
+			name: "rm_package_document",
+			args: []string{"rm", "--overwrite", "--filter", "**/*.opf", "--show-progress=false"},
+			contents: []string{
+				"mimetype",
+				"META-INF/container.xml",
+				"OEBPS/ch1.xhtml",
+				"OEBPS/text/ch2.xhtml",
+			},
+			warnings: []string{
+				"warning: epub: META-INF/container.xml refers to missing OEBPS/content.opf",
+			},
+		},
+		{
+			name: "profile_none",
+			args: []string{"rm", "--overwrite", "--profile", "none", "--filter", "OEBPS/text/*", "--show-progress=false"},
+			contents: []string{
+				"META-INF/container.xml",
+				"mimetype",
+				"OEBPS/content.opf",
+				"OEBPS/ch1.xhtml",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			filename := filepath.Join(tmpdir, "book.epub")
+			helperCreateEPUB(t, filename)
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(append(tt.args, filename))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if len(tt.warnings) != 0 {
+				want = strings.Join(tt.warnings, "\n") + "\n"
+			}
+			if stderr.String() != want {
+				t.Fatalf("error output=%q, want %q", stderr.String(), want)
+			}
+
+			helperRmCheckFileContents(t, filename, tt.contents)
+			if tt.contents[0] == epubMimetypeName {
+				helperCheckEPUBMimetype(t, filename)
+			}
+		})
+	}
+}
+
+func helperCheckEPUBMimetype(t *testing.T, filename string) {
+	t.Helper()
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	zf := zr.File[0]
+	if zf.Method != zip.Store || len(zf.Extra) != 0 || zf.Flags&zip.FlagDataDescriptor != 0 {
+		t.Fatalf("mimetype: method=%d extra=%v flags=%x", zf.Method, zf.Extra, zf.Flags)
+	}
+
+	// readers detect EPUB by the fixed position of the mimetype
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header := string(data[30:58]); header != epubMimetypeName+epubMimetype {
+		t.Fatalf("local header of mimetype=%q", header)
+	}
+}
+
+func TestEPUBProfileWriters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{
+			name: "normalize",
+			args: []string{"normalize", "--epub", "--show-progress=false"},
+		},
+		{
+			name: "merge",
+			args: []string{"merge"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			filename := filepath.Join(tmpdir, "book.epub")
+			helperCreateEPUB(t, filename)
+			outname := filepath.Join(tmpdir, "out.epub")
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(append(tt.args, "--out", outname, filename))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if stderr.Len() != 0 {
+				t.Fatalf("error output=%q", stderr.String())
+			}
+
+			helperCheckEPUBMimetype(t, outname)
+		})
+	}
+}
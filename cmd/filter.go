@@ -0,0 +1,582 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bmatcuk/doublestar"
+	"github.com/hidez8891/zip"
+)
+
+// pathFilter reports whether the zip entry is a target of the command.
+type pathFilter func(*zip.FileHeader) (bool, error)
+
+// nameMatcher reports whether the entry name matches a pattern.
+type nameMatcher func(string) (bool, error)
+
+func (o *cmdParams) globMatcher(pattern string) (nameMatcher, error) {
+	if !o.ignoreCase {
+		return exactGlobMatcher(pattern)
+	}
+	m, err := exactGlobMatcher(strings.ToLower(pattern))
+	if err != nil {
+		return nil, err
+	}
+	return func(s string) (bool, error) {
+		return m(strings.ToLower(s))
+	}, nil
+}
+
+func (o *cmdParams) regexpMatcher(pattern string) (nameMatcher, error) {
+	if o.fullMatch {
+		pattern = "^(?:" + pattern + ")$"
+	}
+	if o.ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return exactRegexpMatcher(pattern)
+}
+
+// exactGlobMatcher matches the pattern without the options of the filters.
+func exactGlobMatcher(pattern string) (nameMatcher, error) {
+	if _, err := doublestar.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return func(s string) (bool, error) {
+		return doublestar.Match(pattern, s)
+	}, nil
+}
+
+// exactRegexpMatcher matches the pattern without the options of the filters.
+func exactRegexpMatcher(pattern string) (nameMatcher, error) {
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return func(s string) (bool, error) {
+		return reg.MatchString(s), nil
+	}, nil
+}
+
+// matchTarget applies m to the part of the entry name that --filter and --regexp are matched against.
+func (o *cmdParams) matchTarget(m nameMatcher) nameMatcher {
+	if !o.basename {
+		return m
+	}
+	return func(name string) (bool, error) {
+		return m(entryBaseName(name))
+	}
+}
+
+func (o *cmdParams) generatePathFilter() (pathFilter, error) {
+	o.filterOnce.Do(func() {
+		o.filter, o.filterErr = o.compilePathFilter()
+	})
+	return o.filter, o.filterErr
+}
+
+func (o *cmdParams) compilePathFilter() (pathFilter, error) {
+	filters := make([]pathFilter, 0)
+
+	if len(o.pattern) != 0 {
+		m, err := o.globMatcher(o.pattern)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, nameFilter(o.matchTarget(m)))
+	}
+	if len(o.regexp) != 0 {
+		m, err := o.regexpMatcher(o.regexp)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, nameFilter(o.matchTarget(m)))
+	}
+
+	includes, err := o.loadPatterns(o.includes, o.includeFrom)
+	if err != nil {
+		return nil, err
+	}
+	if len(includes) != 0 {
+		f, err := o.anyGlobFilter(includes)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	excludes, err := o.loadPatterns(o.excludes, o.excludeFrom)
+	if err != nil {
+		return nil, err
+	}
+	if len(excludes) != 0 {
+		f, err := o.anyGlobFilter(excludes)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, notFilter(f))
+	}
+
+	if len(o.expression) != 0 {
+		f, err := o.parseFilterExpr(o.expression)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	return andFilter(filters...), nil
+}
+
+func (o *cmdParams) loadPatterns(patterns []string, filename string) ([]string, error) {
+	result := append([]string{}, patterns...)
+	if len(filename) == 0 {
+		return result, nil
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *cmdParams) anyGlobFilter(patterns []string) (pathFilter, error) {
+	filters := make([]pathFilter, len(patterns))
+	for i, pattern := range patterns {
+		m, err := o.globMatcher(pattern)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = nameFilter(m)
+	}
+	return orFilter(filters...), nil
+}
+
+func nameFilter(m nameMatcher) pathFilter {
+	return func(h *zip.FileHeader) (bool, error) {
+		return m(h.Name)
+	}
+}
+
+func andFilter(filters ...pathFilter) pathFilter {
+	return func(h *zip.FileHeader) (bool, error) {
+		for _, f := range filters {
+			ok, err := f(h)
+			if err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+func orFilter(filters ...pathFilter) pathFilter {
+	return func(h *zip.FileHeader) (bool, error) {
+		for _, f := range filters {
+			ok, err := f(h)
+			if err != nil || ok {
+				return ok, err
+			}
+		}
+		return false, nil
+	}
+}
+
+func notFilter(filter pathFilter) pathFilter {
+	return func(h *zip.FileHeader) (bool, error) {
+		ok, err := filter(h)
+		return !ok && err == nil, err
+	}
+}
+
+// parseFilterExpr compiles a filter expression.
+//
+//	expr    := and { "or" and }
+//	and     := unary { "and" unary }
+//	unary   := "not" unary | "(" expr ")" | "dir" | "file" | field op value
+//	field   := "name" | "base" | "size" | "csize" | "mtime"
+//	op      := "~" | "!~" | "=~" | "=" | "!=" | "<" | "<=" | ">" | ">="
+//
+// "~" matches a wildcard pattern and "=~" matches a regular expression.
+// Sizes accept the K, M and G suffixes (optionally followed by "B" or "iB").
+func (o *cmdParams) parseFilterExpr(expr string) (pathFilter, error) {
+	tokens, err := tokenizeFilterExpr(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &exprParser{params: o, tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.eof() {
+		return nil, fmt.Errorf("filter expression: unexpected %q", p.peek().text)
+	}
+	return filter, nil
+}
+
+type exprTokenKind int
+
+const (
+	tokenWord exprTokenKind = iota
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type exprToken struct {
+	kind exprTokenKind
+	text string
+}
+
+func tokenizeFilterExpr(expr string) ([]exprToken, error) {
+	tokens := make([]exprToken, 0)
+	runes := []rune(expr)
+
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			tokens = append(tokens, exprToken{tokenLParen, "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, exprToken{tokenRParen, ")"})
+			i++
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != c {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("filter expression: unterminated string")
+			}
+			tokens = append(tokens, exprToken{tokenString, string(runes[i+1 : end])})
+			i = end + 1
+		case strings.ContainsRune("~=!<>", c):
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune("~=!<>", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, exprToken{tokenOperator, string(runes[i:end])})
+			i = end
+		default:
+			end := i + 1
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("()'\"~=!<>", runes[end]) {
+				end++
+			}
+			tokens = append(tokens, exprToken{tokenWord, string(runes[i:end])})
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+type exprParser struct {
+	params *cmdParams
+	tokens []exprToken
+	pos    int
+}
+
+func (p *exprParser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() (exprToken, error) {
+	if p.eof() {
+		return exprToken{}, fmt.Errorf("filter expression: unexpected end")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *exprParser) acceptKeyword(keyword string) bool {
+	if !p.eof() && p.peek().kind == tokenWord && strings.EqualFold(p.peek().text, keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) parseOr() (pathFilter, error) {
+	filters := make([]pathFilter, 0)
+	for {
+		f, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.acceptKeyword("or") {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return orFilter(filters...), nil
+}
+
+func (p *exprParser) parseAnd() (pathFilter, error) {
+	filters := make([]pathFilter, 0)
+	for {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+		if !p.acceptKeyword("and") {
+			break
+		}
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return andFilter(filters...), nil
+}
+
+func (p *exprParser) parseUnary() (pathFilter, error) {
+	if p.acceptKeyword("not") {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notFilter(f), nil
+	}
+
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.kind {
+	case tokenLParen:
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.kind != tokenRParen {
+			return nil, fmt.Errorf("filter expression: missing ')'")
+		}
+		return f, nil
+	case tokenWord:
+		switch strings.ToLower(t.text) {
+		case "dir":
+			return func(h *zip.FileHeader) (bool, error) {
+				return strings.HasSuffix(h.Name, "/"), nil
+			}, nil
+		case "file":
+			return func(h *zip.FileHeader) (bool, error) {
+				return !strings.HasSuffix(h.Name, "/"), nil
+			}, nil
+		}
+		return p.parseComparison(strings.ToLower(t.text))
+	}
+	return nil, fmt.Errorf("filter expression: unexpected %q", t.text)
+}
+
+func (p *exprParser) parseComparison(field string) (pathFilter, error) {
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.kind != tokenOperator {
+		return nil, fmt.Errorf("filter expression: operator is required after %q", field)
+	}
+	value, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if value.kind != tokenWord && value.kind != tokenString {
+		return nil, fmt.Errorf("filter expression: value is required after %q", op.text)
+	}
+
+	switch field {
+	case "name":
+		return p.compileNameComparison(op.text, value.text, func(h *zip.FileHeader) string {
+			return h.Name
+		})
+	case "base":
+		return p.compileNameComparison(op.text, value.text, func(h *zip.FileHeader) string {
+			return entryBaseName(h.Name)
+		})
+	case "size":
+		return compileSizeComparison(op.text, value.text, func(h *zip.FileHeader) uint64 {
+			return h.UncompressedSize64
+		})
+	case "csize":
+		return compileSizeComparison(op.text, value.text, func(h *zip.FileHeader) uint64 {
+			return h.CompressedSize64
+		})
+	case "mtime":
+		return compileTimeComparison(op.text, value.text)
+	}
+	return nil, fmt.Errorf("filter expression: unknown field %q", field)
+}
+
+func (p *exprParser) compileNameComparison(op, value string, field func(*zip.FileHeader) string) (pathFilter, error) {
+	var m nameMatcher
+	var err error
+
+	switch op {
+	case "~", "!~":
+		m, err = exactGlobMatcher(value)
+	case "=~":
+		m, err = exactRegexpMatcher(value)
+	case "=", "!=":
+		m = func(s string) (bool, error) {
+			return s == value, nil
+		}
+	default:
+		return nil, fmt.Errorf("filter expression: operator %q is not supported for names", op)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filter := func(h *zip.FileHeader) (bool, error) {
+		return m(field(h))
+	}
+	if strings.HasPrefix(op, "!") {
+		return notFilter(filter), nil
+	}
+	return filter, nil
+}
+
+func compileSizeComparison(op, value string, field func(*zip.FileHeader) uint64) (pathFilter, error) {
+	size, err := parseSize(value)
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := compareOperator(op)
+	if err != nil {
+		return nil, err
+	}
+	return func(h *zip.FileHeader) (bool, error) {
+		v := field(h)
+		switch {
+		case v < size:
+			return cmp(-1), nil
+		case v > size:
+			return cmp(1), nil
+		}
+		return cmp(0), nil
+	}, nil
+}
+
+func compileTimeComparison(op, value string) (pathFilter, error) {
+	t, err := parseTime(value)
+	if err != nil {
+		return nil, err
+	}
+	cmp, err := compareOperator(op)
+	if err != nil {
+		return nil, err
+	}
+	return func(h *zip.FileHeader) (bool, error) {
+		v := headerModTime(h)
+		switch {
+		case v.Before(t):
+			return cmp(-1), nil
+		case v.After(t):
+			return cmp(1), nil
+		}
+		return cmp(0), nil
+	}, nil
+}
+
+func compareOperator(op string) (func(int) bool, error) {
+	switch op {
+	case "=":
+		return func(c int) bool { return c == 0 }, nil
+	case "!=":
+		return func(c int) bool { return c != 0 }, nil
+	case "<":
+		return func(c int) bool { return c < 0 }, nil
+	case "<=":
+		return func(c int) bool { return c <= 0 }, nil
+	case ">":
+		return func(c int) bool { return c > 0 }, nil
+	case ">=":
+		return func(c int) bool { return c >= 0 }, nil
+	}
+	return nil, fmt.Errorf("filter expression: operator %q is not supported for numbers", op)
+}
+
+func parseSize(s string) (uint64, error) {
+	units := []struct {
+		suffix string
+		scale  uint64
+	}{
+		{"KIB", 1 << 10}, {"MIB", 1 << 20}, {"GIB", 1 << 30},
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30},
+		{"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30},
+		{"B", 1},
+	}
+
+	num := strings.ToUpper(strings.TrimSpace(s))
+	scale := uint64(1)
+	for _, u := range units {
+		if strings.HasSuffix(num, u.suffix) {
+			num = strings.TrimSuffix(num, u.suffix)
+			scale = u.scale
+			break
+		}
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return uint64(v * float64(scale)), nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	layouts := []string{
+		time.RFC3339,
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+func entryBaseName(name string) string {
+	name = strings.TrimSuffix(name, "/")
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFilterRender(t *testing.T) {
+	patternFile, err := ioutil.TempFile("", "patterns*.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(patternFile.Name())
+	patternFile.WriteString("# comment\n\ndir/text2.txt\n")
+	patternFile.Close()
+
+	tests := []struct {
+		name   string
+		args   []string
+		output []string
+	}{
+		{
+			name: "filter_and_regexp",
+			args: []string{
+				"--filter",
+				"dir/*",
+				"--regexp",
+				"1",
+			},
+			output: []string{
+				"dir/text1.txt",
+			},
+		},
+		{
+			name: "multiple_include",
+			args: []string{
+				"--include",
+				"text1.txt",
+				"--include",
+				"dir/text2.txt",
+			},
+			output: []string{
+				"dir/text2.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "include_and_exclude",
+			args: []string{
+				"--include",
+				"**/*.txt",
+				"--exclude",
+				"text1.txt",
+			},
+			output: []string{
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+		{
+			name: "exclude_from",
+			args: []string{
+				"--exclude-from",
+				patternFile.Name(),
+			},
+			output: []string{
+				"dir/",
+				"dir/text1.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "expression_dir",
+			args: []string{
+				"--where",
+				"not dir",
+			},
+			output: []string{
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "expression_size",
+			args: []string{
+				"--where",
+				"name~'**/*.txt' and size>6",
+			},
+			output: []string{
+				"text1.txt",
+			},
+		},
+		{
+			name: "expression_or",
+			args: []string{
+				"--where",
+				"(base='text2.txt' or name=~'^t') and file",
+			},
+			output: []string{
+				"dir/text2.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "ignore_case_glob",
+			args: []string{
+				"--filter",
+				"DIR/Text1.TXT",
+				"--ignore-case",
+			},
+			output: []string{
+				"dir/text1.txt",
+			},
+		},
+		{
+			name: "ignore_case_regexp",
+			args: []string{
+				"--regexp",
+				"^TEXT",
+				"--ignore-case",
+			},
+			output: []string{
+				"text1.txt",
+			},
+		},
+		{
+			name: "full_match_regexp",
+			args: []string{
+				"--regexp",
+				"text1\\.txt",
+				"--full-match",
+			},
+			output: []string{
+				"text1.txt",
+			},
+		},
+		{
+			name: "basename_glob",
+			args: []string{
+				"--filter",
+				"*1.txt",
+				"--basename",
+			},
+			output: []string{
+				"dir/text1.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "basename_full_match_regexp",
+			args: []string{
+				"--regexp",
+				"dir",
+				"--basename",
+				"--full-match",
+			},
+			output: []string{
+				"dir/",
+			},
+		},
+		{
+			name: "expression_without_filter_options",
+			args: []string{
+				"--where",
+				"name~'dir/*.txt' and not name~'*/TEXT1.txt'",
+				"--basename",
+				"--ignore-case",
+			},
+			output: []string{
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			args := append([]string{"ls", "../testcase/test.zip"}, tt.args...)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+
+			want := strings.Join(tt.output, "\n") + "\n"
+			if out := stdout.String(); out != want {
+				t.Fatalf("output=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestFilterExpressionError(t *testing.T) {
+	tests := []string{
+		"name",
+		"name~",
+		"size>abc",
+		"(dir",
+		"color='red'",
+		"name<'a'",
+	}
+
+	params := &cmdParams{}
+	for _, expr := range tests {
+		if _, err := params.parseFilterExpr(expr); err == nil {
+			t.Fatalf("expression %q is accepted, want error", expr)
+		}
+	}
+}
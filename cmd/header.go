@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+
+	"github.com/hidez8891/zip"
+)
+
+const (
+	zip64ExtraID   = 0x0001
+	ntfsExtraID    = 0x000a
+	extTimeExtraID = 0x5455
+	unixIDExtraID  = 0x7855 // Info-ZIP Unix type 2, the UID and GID
+	unixNewExtraID = 0x7875 // Info-ZIP new Unix, the UID and GID
+)
+
+// setModTime sets t to both the MS-DOS time fields and
+// the extended timestamp extra field of h.
+// The NTFS extra field is removed not to keep the old time.
+// t out of the range of the extended timestamp is set only to
+// the MS-DOS time fields.
+func setModTime(h *zip.FileHeader, t time.Time) {
+	h.Modified = t
+	h.ModifiedDate, h.ModifiedTime = timeToMsDosTime(t)
+	h.Extra = removeExtraField(h.Extra, ntfsExtraID)
+	if t.Unix() < 0 || t.Unix() > math.MaxUint32 {
+		h.Modified = time.Time{}
+		h.Extra = removeExtraField(h.Extra, extTimeExtraID)
+		return
+	}
+
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:], extTimeExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 5)
+	extra[4] = 1 // modification time only
+	binary.LittleEndian.PutUint32(extra[5:], uint32(t.Unix()))
+
+	h.Extra = append(removeExtraField(h.Extra, extTimeExtraID), extra...)
+}
+
+// headerModTime returns the modification time of h from the extra fields,
+// or from the MS-DOS time fields without them.
+func headerModTime(h *zip.FileHeader) time.Time {
+	if h.Modified.IsZero() {
+		return h.ModTime()
+	}
+	return h.Modified
+}
+
+func timeToMsDosTime(t time.Time) (uint16, uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	if t.Year() > 2107 {
+		t = time.Date(2107, 12, 31, 23, 59, 58, 0, t.Location())
+	}
+	date := uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	clock := uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return date, clock
+}
+
+// removeExtraField returns extra without the fields of id.
+func removeExtraField(extra []byte, id uint16) []byte {
+	result := make([]byte, 0, len(extra))
+	for len(extra) >= 4 {
+		size := int(binary.LittleEndian.Uint16(extra[2:])) + 4
+		if size > len(extra) {
+			break
+		}
+		if binary.LittleEndian.Uint16(extra) != id {
+			result = append(result, extra[:size]...)
+		}
+		extra = extra[size:]
+	}
+	return append(result, extra...)
+}
+
+func findHeader(zu *zip.Updater, name string) *zip.FileHeader {
+	for _, header := range zu.Files() {
+		if header.Name == name {
+			return header
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	path "path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// endOfDirectoryLen is the length of the end of central directory record without the comment.
+const endOfDirectoryLen = 22
+
+var zipSignatures = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("PK\x05\x06"),
+	[]byte("PK\x07\x08"),
+}
+
+// hasZipSignature reports whether the file starts with a zip signature,
+// or has the end of central directory record like self-extracting archives.
+func hasZipSignature(filepath string) (bool, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(file, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, sig := range zipSignatures {
+		if bytes.Equal(buf, sig) {
+			return true, nil
+		}
+	}
+
+	return hasEndOfCentralDirectory(file)
+}
+
+// hasEndOfCentralDirectory searches the end of central directory record
+// followed only by its comment at the end of the file.
+func hasEndOfCentralDirectory(file *os.File) (bool, error) {
+	st, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	size := st.Size()
+	if size > endOfDirectoryLen+maxCommentLen {
+		size = endOfDirectoryLen + maxCommentLen
+	}
+	buf := make([]byte, size)
+	if _, err := file.ReadAt(buf, st.Size()-size); err != nil && err != io.EOF {
+		return false, err
+	}
+
+	for i := len(buf) - endOfDirectoryLen; i >= 0; i-- {
+		if !bytes.Equal(buf[i:i+4], zipSignatures[1]) {
+			continue
+		}
+		commentLen := int(binary.LittleEndian.Uint16(buf[i+endOfDirectoryLen-2:]))
+		if i+endOfDirectoryLen+commentLen == len(buf) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// requireInputs is a cobra.PositionalArgs that accepts no arguments
+// when the input files are read from --files-from.
+func (o *cmdParams) requireInputs(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && len(o.filesFrom) == 0 {
+		return fmt.Errorf("requires at least 1 arg(s), only received 0")
+	}
+	return nil
+}
+
+func (o *cmdParams) readFilesFrom() ([]string, error) {
+	if len(o.filesFrom) == 0 {
+		return nil, nil
+	}
+
+	var r io.Reader = o.stdin
+	if o.filesFrom != stdioPath {
+		file, err := os.Open(o.filesFrom)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	sep := byte('\n')
+	if o.nullSep {
+		sep = 0
+	}
+
+	paths := make([]string, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) != 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	for scanner.Scan() {
+		p := strings.TrimSuffix(scanner.Text(), "\r")
+		if !o.nullSep {
+			p = strings.TrimSpace(p)
+		}
+		if len(p) != 0 {
+			paths = append(paths, p)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// walkArchives returns the archive files under dir.
+func (o *cmdParams) walkArchives(dir string) ([]string, error) {
+	paths := make([]string, 0)
+	err := path.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		ok, err := o.isArchiveFile(p)
+		if err != nil {
+			return err
+		}
+		if ok {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func (o *cmdParams) isArchiveFile(filepath string) (bool, error) {
+	if o.detectMagic {
+		return hasZipSignature(filepath)
+	}
+
+	lower := strings.ToLower(filepath)
+	for _, ext := range o.archiveExts {
+		ext = strings.ToLower(ext)
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		if strings.HasSuffix(lower, ext) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// resolvePath returns the absolute path of filepath without symlinks.
+func resolvePath(filepath string) (string, error) {
+	resolved, err := path.EvalSymlinks(filepath)
+	if err != nil {
+		return "", err
+	}
+	return path.Abs(resolved)
+}
+
+// filterArchivePaths drops duplicated paths and files that are not zip archives.
+// Paths that cannot be inspected are kept so that the command reports the error.
+func (o *cmdParams) filterArchivePaths(paths []string) []string {
+	newpaths := make([]string, 0, len(paths))
+	seen := make(map[string]bool)
+
+	for _, p := range paths {
+		apath := parseArchivePath(p)
+		if apath.file == stdioPath {
+			newpaths = append(newpaths, p)
+			continue
+		}
+
+		// identify the same file reached through different paths or symlinks
+		file, err := resolvePath(apath.file)
+		if err != nil {
+			newpaths = append(newpaths, p)
+			continue
+		}
+
+		key := file + strings.TrimPrefix(p, apath.file)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if ok, err := hasZipSignature(apath.file); err == nil && !ok {
+			fmt.Fprintf(o.stderr, "skip: %s: not a zip archive\n", apath.file)
+			continue
+		}
+		newpaths = append(newpaths, p)
+	}
+	return newpaths
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInputRecursiveRender(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	a := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "a.zip"))
+	b := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "sub", "b.jar"))
+	c := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "sub", "c.bin"))
+	if err := ioutil.WriteFile(filepath.Join(tmpdir, "sub", "readme.txt"), []byte("readme"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		stdin  string
+		args   []string
+		output []string
+	}{
+		{
+			name: "extension",
+			args: []string{"--recursive", tmpdir},
+			output: []string{
+				a + ":", "text1.txt", "",
+				b + ":", "text1.txt",
+			},
+		},
+		{
+			name: "magic",
+			args: []string{"--recursive", "--detect-magic", tmpdir},
+			output: []string{
+				a + ":", "text1.txt", "",
+				b + ":", "text1.txt", "",
+				c + ":", "text1.txt",
+			},
+		},
+		{
+			name:  "files_from_stdin",
+			stdin: c + "\n" + a + "\n",
+			args:  []string{"--files-from", "-"},
+			output: []string{
+				c + ":", "text1.txt", "",
+				a + ":", "text1.txt",
+			},
+		},
+		{
+			name:  "files_from_null",
+			stdin: b + "\x00" + c + "\x00",
+			args:  []string{"--files-from", "-", "--null"},
+			output: []string{
+				b + ":", "text1.txt", "",
+				c + ":", "text1.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			args := append([]string{"ls", "--filter", "text1.txt"}, tt.args...)
+			cmd := newRootCmd(strings.NewReader(tt.stdin), stdout, stderr)
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+
+			want := strings.Join(tt.output, "\n") + "\n"
+			if out := stdout.String(); out != want {
+				t.Fatalf("output=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestInputDirectoryWithoutRecursive(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"ls", "../testcase"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatalf("directory input is accepted without --recursive")
+	}
+}
+
+func TestInputSkipAndDeduplicate(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	a := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "a.zip"))
+	text := filepath.Join(tmpdir, "b.zip")
+	if err := ioutil.WriteFile(text, []byte("not a zip file"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmpdir, "link.zip")
+	if err := os.Symlink(a, link); err != nil {
+		t.Skip(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"ls",
+		"--filter",
+		"text1.txt",
+		a,
+		filepath.Join(tmpdir, "*.zip"),
+		filepath.Join(tmpdir, "none", "*.zip"),
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if out, want := stdout.String(), "text1.txt\n"; out != want {
+		t.Fatalf("output=%q, want %q", out, want)
+	}
+
+	errout := stderr.String()
+	if !strings.Contains(errout, "skip: "+text) {
+		t.Fatalf("non-zip file is not reported: %q", errout)
+	}
+	if !strings.Contains(errout, "no files matched") {
+		t.Fatalf("empty glob is not reported: %q", errout)
+	}
+}
+
+func TestInputFailOnEmptyGlob(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"ls",
+		"--fail-on-empty-glob",
+		"../testcase/test.zip",
+		"../testcase/none/*.zip",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stdout.Len() != 0 {
+		t.Fatalf("stdout output: %q", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "no files matched") {
+		t.Fatalf("error output=%q", stderr.String())
+	}
+}
+
+func TestHasZipSignature(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	data, err := ioutil.ReadFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"zip", data, true},
+		{"self_extracting", append([]byte("MZ stub program"), data...), true},
+		{"text", []byte("not a zip file"), false},
+		{"short", []byte("PK"), false},
+	}
+
+	for _, tt := range tests {
+		filename := filepath.Join(tmpdir, tt.name)
+		if err := ioutil.WriteFile(filename, tt.data, 0666); err != nil {
+			t.Fatal(err)
+		}
+		ok, err := hasZipSignature(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tt.want {
+			t.Fatalf("%s: hasZipSignature=%v, want %v", tt.name, ok, tt.want)
+		}
+	}
+}
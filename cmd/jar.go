@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/hidez8891/zip"
+)
+
+const (
+	jarProfileName   = "jar"
+	jarMetaDir       = "META-INF/"
+	jarManifestName  = "META-INF/MANIFEST.MF"
+	apkManifestName  = "AndroidManifest.xml"
+	signatureWarn    = "warn"
+	signatureStrip   = "strip"
+	manifestLineSize = 72
+)
+
+// jarProfile keeps the manifest first, and handles the signature
+// which is invalidated by changes of the contents.
+var jarProfile = &archiveProfile{
+	name:    jarProfileName,
+	leading: []string{jarMetaDir, jarManifestName},
+	detect:  isJAR,
+	fixup:   (*cmdParams).fixupJAR,
+}
+
+// isJAR reports whether zu has the manifest of JAR. APKs have the manifest
+// too, but their signature is not handled in the same way.
+func isJAR(zu *zip.Updater) bool {
+	return findHeader(zu, jarManifestName) != nil && findHeader(zu, apkManifestName) == nil
+}
+
+// isSignatureFile reports whether name is a signature related file of a signed JAR.
+func isSignatureFile(name string) bool {
+	if !strings.HasPrefix(name, jarMetaDir) || strings.Contains(name[len(jarMetaDir):], "/") {
+		return false
+	}
+	base := strings.ToUpper(name[len(jarMetaDir):])
+	for _, ext := range []string{".SF", ".RSA", ".DSA", ".EC"} {
+		if strings.HasSuffix(base, ext) {
+			return true
+		}
+	}
+	return strings.HasPrefix(base, "SIG-")
+}
+
+func (o *cmdParams) fixupJAR(zu *zip.Updater, changes *entryChanges, warn func(string, ...interface{})) error {
+	if err := moveManifestFirst(zu); err != nil {
+		return err
+	}
+
+	signatures := make([]string, 0)
+	for _, header := range zu.Files() {
+		if isSignatureFile(header.Name) {
+			signatures = append(signatures, header.Name)
+		}
+	}
+	if len(signatures) == 0 || !changesContents(changes) {
+		return nil
+	}
+
+	if o.signature != signatureStrip {
+		for _, name := range signatures {
+			warn("signature %s is invalidated by the changes (use --jar-signature strip)", name)
+		}
+		return nil
+	}
+
+	for _, name := range signatures {
+		if err := zu.Remove(name); err != nil {
+			return err
+		}
+	}
+	return stripManifestDigests(zu)
+}
+
+// changesContents reports whether changes affect the signed contents.
+func changesContents(changes *entryChanges) bool {
+	names := append(append(append([]string{}, changes.added...), changes.removed...), changes.modified...)
+	for oldname, newname := range changes.renamed {
+		names = append(names, oldname, newname)
+	}
+	for _, name := range names {
+		if !isSignatureFile(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// moveManifestFirst moves META-INF/ and the manifest to the top as the jar tool does.
+func moveManifestFirst(zu *zip.Updater) error {
+	headers := zu.Files()
+	first := []string{jarManifestName}
+	if findHeader(zu, jarMetaDir) != nil {
+		first = []string{jarMetaDir, jarManifestName}
+	}
+
+	inOrder := len(headers) >= len(first)
+	for i, name := range first {
+		if inOrder && headers[i].Name != name {
+			inOrder = false
+		}
+	}
+	if inOrder {
+		return nil
+	}
+
+	return zu.Sort(func(names []string) []string {
+		sorted := append([]string{}, first...)
+		for _, name := range names {
+			if name != jarMetaDir && name != jarManifestName {
+				sorted = append(sorted, name)
+			}
+		}
+		return sorted
+	})
+}
+
+// stripManifestDigests removes the digests of the entries from the manifest,
+// and the sections which have no other attributes.
+func stripManifestDigests(zu *zip.Updater) error {
+	manifest, err := readManifest(zu)
+	if err != nil {
+		return err
+	}
+
+	sections := manifest.sections[:1]
+	for _, section := range manifest.sections[1:] {
+		attrs := make(manifestSection, 0, len(section))
+		for _, attr := range section {
+			if !strings.HasSuffix(strings.ToLower(attr.name), "-digest") {
+				attrs = append(attrs, attr)
+			}
+		}
+		if len(attrs) > 1 {
+			sections = append(sections, attrs)
+		}
+	}
+	manifest.sections = sections
+	return writeUpdaterEntry(zu, jarManifestName, manifest.bytes())
+}
+
+type manifestAttr struct {
+	name  string
+	value string
+}
+
+type manifestSection []manifestAttr
+
+// jarManifest is the contents of MANIFEST.MF.
+// The first section is the main section.
+type jarManifest struct {
+	sections []manifestSection
+}
+
+func (m *jarManifest) get(name string) (string, bool) {
+	for _, attr := range m.sections[0] {
+		if strings.EqualFold(attr.name, name) {
+			return attr.value, true
+		}
+	}
+	return "", false
+}
+
+func (m *jarManifest) set(name, value string) {
+	for i, attr := range m.sections[0] {
+		if strings.EqualFold(attr.name, name) {
+			m.sections[0][i].value = value
+			return
+		}
+	}
+	m.sections[0] = append(m.sections[0], manifestAttr{name, value})
+}
+
+func (m *jarManifest) unset(name string) {
+	attrs := make(manifestSection, 0, len(m.sections[0]))
+	for _, attr := range m.sections[0] {
+		if !strings.EqualFold(attr.name, name) {
+			attrs = append(attrs, attr)
+		}
+	}
+	m.sections[0] = attrs
+}
+
+func parseManifest(data []byte) (*jarManifest, error) {
+	text := strings.Replace(string(data), "\r\n", "\n", -1)
+	text = strings.Replace(text, "\r", "\n", -1)
+
+	manifest := &jarManifest{sections: []manifestSection{{}}}
+	section := &manifest.sections[0]
+	blank := false
+	for i, line := range strings.Split(text, "\n") {
+		switch {
+		case len(line) == 0:
+			blank = true
+			continue
+		case strings.HasPrefix(line, " "):
+			if len(*section) == 0 || blank {
+				return nil, fmt.Errorf("manifest line %d: unexpected continuation", i+1)
+			}
+			(*section)[len(*section)-1].value += line[1:]
+			continue
+		}
+
+		if blank {
+			manifest.sections = append(manifest.sections, manifestSection{})
+			section = &manifest.sections[len(manifest.sections)-1]
+			blank = false
+		}
+
+		sep := strings.Index(line, ": ")
+		if sep <= 0 {
+			return nil, fmt.Errorf("manifest line %d: invalid attribute %q", i+1, line)
+		}
+		*section = append(*section, manifestAttr{line[:sep], line[sep+2:]})
+	}
+	return manifest, nil
+}
+
+// bytes returns the manifest with the lines wrapped at 72 bytes.
+func (m *jarManifest) bytes() []byte {
+	buf := new(bytes.Buffer)
+	for _, section := range m.sections {
+		for _, attr := range section {
+			line := attr.name + ": " + attr.value
+			size := manifestLineSize
+			for len(line) > size {
+				buf.WriteString(line[:size] + "\r\n ")
+				line = line[size:]
+				size = manifestLineSize - 1
+			}
+			buf.WriteString(line + "\r\n")
+		}
+		buf.WriteString("\r\n")
+	}
+	return buf.Bytes()
+}
+
+func readManifest(zu *zip.Updater) (*jarManifest, error) {
+	data, err := readUpdaterEntry(zu, jarManifestName)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(data)
+}
@@ -0,0 +1,297 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+const testJARManifest = "Manifest-Version: 1.0\r\n" +
+	"Created-By: test\r\n" +
+	"\r\n" +
+	"Name: com/A.class\r\n" +
+	"SHA-256-Digest: 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=\r\n" +
+	"\r\n" +
+	"Name: com/B.class\r\n" +
+	"Sealed: true\r\n" +
+	"SHA-256-Digest: 47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=\r\n" +
+	"\r\n"
+
+// helperCreateJAR creates a signed-like JAR whose manifest is not first.
+func helperCreateJAR(t *testing.T, filename string) {
+	t.Helper()
+
+	helperCreateArchive(t, filename, []testEntry{
+		{name: "com/A.class", body: "A"},
+		{name: "META-INF/"},
+		{name: "META-INF/MANIFEST.MF", body: testJARManifest},
+		{name: "META-INF/CERT.SF", body: "Signature-Version: 1.0\r\n\r\n"},
+		{name: "META-INF/CERT.RSA", body: "signature"},
+		{name: "com/B.class", body: "B"},
+	})
+}
+
+func helperReadEntry(t *testing.T, filename, name string) string {
+	t.Helper()
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	for _, zf := range zr.File {
+		if zf.Name != name {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Close()
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	t.Fatalf("%s is not found", name)
+	return ""
+}
+
+func TestJARProfile(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+		warnings []string
+		manifest string
+	}{
+		{
+			name: "rm_warn",
+			args: []string{"rm", "--overwrite", "--filter", "com/B.class", "--show-progress=false"},
+			contents: []string{
+				"META-INF/",
+				"META-INF/MANIFEST.MF",
+				"com/A.class",
+				"META-INF/CERT.SF",
+				"META-INF/CERT.RSA",
+			},
+			warnings: []string{
+				"warning: jar: signature META-INF/CERT.SF is invalidated by the changes (use --jar-signature strip)",
+				"warning: jar: signature META-INF/CERT.RSA is invalidated by the changes (use --jar-signature strip)",
+			},
+			manifest: testJARManifest,
+		},
+		{
+			name: "rm_strip",
+			args: []string{"rm", "--overwrite", "--jar-signature", "strip", "--filter", "com/B.class", "--show-progress=false"},
+			contents: []string{
+				"META-INF/",
+				"META-INF/MANIFEST.MF",
+				"com/A.class",
+			},
+			manifest: "Manifest-Version: 1.0\r\n" +
+				"Created-By: test\r\n" +
+				"\r\n" +
+				"Name: com/B.class\r\n" +
+				"Sealed: true\r\n" +
+				"\r\n",
+		},
+		{
+			name: "rm_signature",
+			args: []string{"rm", "--overwrite", "--filter", "META-INF/CERT.*", "--show-progress=false"},
+			contents: []string{
+				"META-INF/",
+				"META-INF/MANIFEST.MF",
+				"com/A.class",
+				"com/B.class",
+			},
+			manifest: testJARManifest,
+		},
+		{
+			name: "unknown_signature_handling",
+			args: []string{"rm", "--overwrite", "--jar-signature", "strp", "--filter", "com/B.class", "--show-progress=false"},
+			contents: []string{
+				"com/A.class",
+				"META-INF/",
+				"META-INF/MANIFEST.MF",
+				"META-INF/CERT.SF",
+				"META-INF/CERT.RSA",
+				"com/B.class",
+			},
+			warnings: []string{
+				"unknown JAR signature handling: strp",
+			},
+			manifest: testJARManifest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			filename := filepath.Join(tmpdir, "app.jar")
+			helperCreateJAR(t, filename)
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(append(tt.args, filename))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if len(tt.warnings) != 0 {
+				want = strings.Join(tt.warnings, "\n") + "\n"
+			}
+			if stderr.String() != want {
+				t.Fatalf("error output=%q, want %q", stderr.String(), want)
+			}
+
+			helperRmCheckFileContents(t, filename, tt.contents)
+			if manifest := helperReadEntry(t, filename, jarManifestName); manifest != tt.manifest {
+				t.Fatalf("manifest=%q, want %q", manifest, tt.manifest)
+			}
+		})
+	}
+}
+
+func TestIsJAR(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  bool
+	}{
+		{"jar", []string{"META-INF/MANIFEST.MF", "com/A.class"}, true},
+		{"apk", []string{"AndroidManifest.xml", "META-INF/MANIFEST.MF", "classes.dex"}, false},
+		{"zip", []string{"text1.txt"}, false},
+	}
+
+	for _, tt := range tests {
+		var entries []testEntry
+		for _, name := range tt.names {
+			entries = append(entries, testEntry{name: name})
+		}
+		data := helperArchiveData(t, entries)
+
+		zu, err := zip.NewUpdater(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := isJAR(zu); got != tt.want {
+			t.Fatalf("%s: isJAR=%v, want %v", tt.name, got, tt.want)
+		}
+		zu.Close()
+	}
+}
+
+func TestParseManifest(t *testing.T) {
+	value := strings.Repeat("lib/a.jar ", 20)
+	data := "Manifest-Version: 1.0\r\n" +
+		"Class-Path: " + value[:60] + "\r\n" +
+		" " + value[60:131] + "\r\n" +
+		" " + value[131:] + "\r\n" +
+		"\r\n"
+
+	m, err := parseManifest([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := m.get("class-path"); got != value {
+		t.Fatalf("Class-Path=%q, want %q", got, value)
+	}
+	if got := string(m.bytes()); got != data {
+		t.Fatalf("bytes=%q, want %q", got, data)
+	}
+
+	if _, err := parseManifest([]byte(" continued\r\n")); err == nil {
+		t.Fatal("continuation without attribute must be an error")
+	}
+	if _, err := parseManifest([]byte("Invalid\r\n")); err == nil {
+		t.Fatal("attribute without separator must be an error")
+	}
+}
+
+func TestManifestCmd(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "app.jar")
+	helperCreateJAR(t, filename)
+
+	tests := []struct {
+		name   string
+		args   []string
+		stdout string
+		stderr string
+	}{
+		{
+			name: "set",
+			args: []string{"manifest", "--overwrite", "--jar-signature", "strip", "--set", "Main-Class=com.A", "--set", "created-by=ziped", "--show-progress=false"},
+		},
+		{
+			name:   "show",
+			args:   []string{"manifest"},
+			stdout: "Manifest-Version: 1.0\nCreated-By: ziped\nMain-Class: com.A\n",
+		},
+		{
+			name:   "get",
+			args:   []string{"manifest", "--get", "main-class"},
+			stdout: "com.A\n",
+		},
+		{
+			name: "unset",
+			args: []string{"manifest", "--overwrite", "--unset", "Created-By", "--show-progress=false"},
+		},
+		{
+			name:   "get_removed",
+			args:   []string{"manifest", "--get", "Created-By"},
+			stderr: filename + ": attribute Created-By is not found\n",
+		},
+		{
+			name:   "set_invalid",
+			args:   []string{"manifest", "--overwrite", "--set", "Main Class=com.A"},
+			stderr: "invalid attribute name \"Main Class\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+		cmd.SetArgs(append(tt.args, filename))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if stdout.String() != tt.stdout {
+			t.Fatalf("%s: output=%q, want %q", tt.name, stdout.String(), tt.stdout)
+		}
+		if stderr.String() != tt.stderr {
+			t.Fatalf("%s: error output=%q, want %q", tt.name, stderr.String(), tt.stderr)
+		}
+	}
+
+	helperRmCheckFileContents(t, filename, []string{
+		"META-INF/",
+		"META-INF/MANIFEST.MF",
+		"com/A.class",
+		"com/B.class",
+	})
+}
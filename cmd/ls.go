@@ -15,25 +15,31 @@ func newLsCmd(params *cmdParams) *cobra.Command {
 	var cmd = &cobra.Command{
 		Use:   "ls [filepath...]",
 		Short: "Show file list",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  params.requireInputs,
 		Run: func(cmd *cobra.Command, args []string) {
 			lscmd.run(cmd, args)
 		},
 	}
 
+	cmd.Flags().BoolVarP(&lscmd.long, "long", "l", false, "show mode, size and modification time")
 	return cmd
 }
 
 type ls struct {
 	*baseCmd
+	long bool
 }
 
 func (o *ls) run(cmd *cobra.Command, args []string) {
-	paths, err := expandFilePath(args)
+	paths, err := o.expandFilePath(args)
 	if err != nil {
 		fmt.Fprintln(o.stderr, err)
 		return
 	}
+	if err := validateStdinPaths(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
 
 	if len(paths) == 1 {
 		files, err := o.execute(paths[0])
@@ -67,27 +73,59 @@ func (o *ls) run(cmd *cobra.Command, args []string) {
 }
 
 func (o *ls) execute(filepath string) ([]string, error) {
-	result := make([]string, 0)
+	apath := parseArchivePath(filepath)
 
-	zr, err := zip.OpenReader(filepath)
+	zr, closer, err := o.openZipReader(apath)
 	if err != nil {
 		return nil, err
 	}
-	defer zr.Close()
+	defer closer.Close()
+
+	return o.list(zr, apath.scopeFilter(), "")
+}
+
+func (o *ls) list(zr *zip.Reader, scope pathFilter, prefix string) ([]string, error) {
+	result := make([]string, 0)
 
 	filter, err := o.generatePathFilter()
 	if err != nil {
 		return nil, err
 	}
+	filter = andFilter(scope, filter)
 
 	for _, zf := range zr.File {
-		ok, err := filter(zf.Name)
+		ok, err := filter(&zf.FileHeader)
 		if err != nil {
 			return nil, err
 		}
 		if ok {
-			result = append(result, zf.Name)
+			result = append(result, o.format(zf, prefix))
+		}
+
+		if !o.nested || !isArchiveName(zf.Name) {
+			continue
+		}
+		if ok, _ := scope(&zf.FileHeader); !ok {
+			continue
 		}
+
+		inner, err := openNestedReader(zr, zf.Name)
+		if err != nil {
+			return nil, err
+		}
+		files, err := o.list(inner, archivePath{}.scopeFilter(), prefix+zf.Name+nestedSeparator)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, files...)
 	}
 	return result, nil
 }
+
+func (o *ls) format(zf *zip.File, prefix string) string {
+	if !o.long {
+		return prefix + zf.Name
+	}
+	mtime := headerModTime(&zf.FileHeader)
+	return fmt.Sprintf("%s %10d %s %s", zf.Mode(), zf.UncompressedSize64, mtime.Format("2006-01-02 15:04"), prefix+zf.Name)
+}
@@ -59,13 +59,26 @@ func TestLsRender(t *testing.T) {
 				"text1.txt",
 			}, "\n") + "\n",
 		},
+		{
+			args: []string{
+				"ls",
+				"-l",
+				"../testcase/test.zip",
+				"--filter",
+				"dir/*",
+			},
+			output: strings.Join([]string{
+				"-rw-rw-rw-          6 2018-09-17 15:43 dir/text1.txt",
+				"-rw-rw-rw-          6 2018-09-17 15:43 dir/text2.txt",
+			}, "\n") + "\n",
+		},
 	}
 
 	for _, tt := range tests {
 		stdout := new(bytes.Buffer)
 		stderr := new(bytes.Buffer)
 
-		cmd := newRootCmd(stdout, stderr)
+		cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
 		cmd.SetArgs(tt.args)
 		if err := cmd.Execute(); err != nil {
 			t.Fatal(err)
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newManifestCmd(params *cmdParams) *cobra.Command {
+	manifestcmd := &manifest{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "manifest [filepath...]",
+		Short: "Show or edit JAR manifest",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manifestcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&manifestcmd.get, "get", "", "show value of main attribute")
+	cmd.Flags().StringArrayVar(&manifestcmd.set, "set", nil, "set main attribute NAME=VALUE (repeatable)")
+	cmd.Flags().StringArrayVar(&manifestcmd.unset, "unset", nil, "remove main attribute (repeatable)")
+	manifestcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type manifest struct {
+	*baseCmd
+	pexe  *toolParallelCmd
+	get   string
+	set   []string
+	unset []string
+	attrs []manifestAttr
+}
+
+func (o *manifest) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	isEdit := len(o.set) != 0 || len(o.unset) != 0
+	if isEdit && len(o.get) != 0 {
+		fmt.Fprintln(o.stderr, "--get cannot be used with --set and --unset")
+		return
+	}
+	if !isEdit {
+		o.show(paths)
+		return
+	}
+
+	if err := o.validate(); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *manifest) validate() error {
+	for _, s := range o.set {
+		sep := strings.Index(s, "=")
+		if sep <= 0 {
+			return fmt.Errorf("invalid attribute %q (NAME=VALUE)", s)
+		}
+		name := s[:sep]
+		if !isManifestName(name) {
+			return fmt.Errorf("invalid attribute name %q", name)
+		}
+		if strings.ContainsAny(s[sep+1:], "\r\n") {
+			return fmt.Errorf("attribute %s: value contains newline", name)
+		}
+		o.attrs = append(o.attrs, manifestAttr{name, s[sep+1:]})
+	}
+	return nil
+}
+
+// isManifestName reports whether name is a valid header name of the JAR specification.
+func isManifestName(name string) bool {
+	if len(name) == 0 || len(name) > 70 {
+		return false
+	}
+	for _, c := range name {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case c == '-' || c == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (o *manifest) execute(filepath string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		m := &jarManifest{sections: []manifestSection{{{"Manifest-Version", "1.0"}}}}
+		if findHeader(zu, jarManifestName) != nil {
+			var err error
+			if m, err = readManifest(zu); err != nil {
+				return false, err
+			}
+		}
+
+		before := string(m.bytes())
+		for _, name := range o.unset {
+			m.unset(name)
+		}
+		for _, attr := range o.attrs {
+			m.set(attr.name, attr.value)
+		}
+		if string(m.bytes()) == before && findHeader(zu, jarManifestName) != nil {
+			return false, nil
+		}
+		return true, writeUpdaterEntry(zu, jarManifestName, m.bytes())
+	})
+}
+
+func (o *manifest) show(paths []string) {
+	if err := validateStdinPaths(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	for i, filepath := range paths {
+		lines, err := o.attributes(filepath)
+		if err != nil {
+			fmt.Fprintln(o.stderr, err)
+			return
+		}
+
+		if len(paths) != 1 {
+			if len(lines) == 0 {
+				continue
+			}
+			fmt.Fprintf(o.stdout, "%s:\n", filepath)
+		}
+		for _, line := range lines {
+			fmt.Fprintln(o.stdout, line)
+		}
+		if len(paths) != 1 && i != len(paths)-1 {
+			fmt.Fprintln(o.stdout)
+		}
+	}
+}
+
+// attributes returns "name: value" of the main attributes, or the value of --get.
+func (o *manifest) attributes(filepath string) ([]string, error) {
+	zr, closer, err := o.openZipReader(parseArchivePath(filepath))
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var data []byte
+	for _, zf := range zr.File {
+		if zf.Name != jarManifestName {
+			continue
+		}
+		r, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err = ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if data == nil {
+		return nil, fmt.Errorf("%s: %s is not found", filepath, jarManifestName)
+	}
+
+	m, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filepath, err)
+	}
+
+	if len(o.get) != 0 {
+		value, ok := m.get(o.get)
+		if !ok {
+			return nil, fmt.Errorf("%s: attribute %s is not found", filepath, o.get)
+		}
+		return []string{value}, nil
+	}
+
+	lines := make([]string, 0, len(m.sections[0]))
+	for _, attr := range m.sections[0] {
+		lines = append(lines, attr.name+": "+attr.value)
+	}
+	return lines, nil
+}
@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	path "path/filepath"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newMergeCmd(params *cmdParams) *cobra.Command {
+	mergecmd := &merge{
+		baseCmd: &baseCmd{params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "merge [filepath...]",
+		Short: "Merge archives into one archive",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			mergecmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&mergecmd.prefixes, "prefix", nil, "path prefix of source entries as SRC=PREFIX (repeatable)")
+	cmd.Flags().StringVar(&mergecmd.conflict, "conflict", conflictFirstWins, "conflict policy (first-wins, last-wins, error, rename)")
+	return cmd
+}
+
+type merge struct {
+	*baseCmd
+	prefixes []string
+	conflict string
+}
+
+const (
+	conflictFirstWins = "first-wins"
+	conflictLastWins  = "last-wins"
+	conflictError     = "error"
+	conflictRename    = "rename"
+)
+
+// mergeEntry is an entry of the merged archive copied from the source entry.
+type mergeEntry struct {
+	name   string
+	source string
+	file   *zip.File
+}
+
+func (o *merge) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.validate(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.execute(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+func (o *merge) validate(paths []string) error {
+	if err := o.validateProfileFlag(); err != nil {
+		return err
+	}
+	switch o.conflict {
+	case conflictFirstWins, conflictLastWins, conflictError, conflictRename:
+	default:
+		return fmt.Errorf("unknown conflict policy: %s", o.conflict)
+	}
+
+	if o.isOverwrite || len(o.outDir) != 0 || isOutputTemplate(o.outFilename) {
+		return fmt.Errorf("merge supports only an output file name")
+	}
+	if len(o.outFilename) == 0 {
+		return fmt.Errorf("output file name is required")
+	}
+	return validateStdinPaths(paths)
+}
+
+func (o *merge) execute(paths []string) error {
+	prefixes, err := parsePrefixes(o.prefixes)
+	if err != nil {
+		return err
+	}
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return err
+	}
+
+	closers := make([]io.Closer, 0, len(paths))
+	defer func() {
+		for _, c := range closers {
+			close(c)
+		}
+	}()
+
+	entries := make([]*mergeEntry, 0)
+	index := make(map[string]int)
+	for _, p := range paths {
+		apath := parseArchivePath(p)
+		zr, closer, err := o.openZipReader(apath)
+		if err != nil {
+			return err
+		}
+		closers = append(closers, closer)
+
+		scope := andFilter(apath.scopeFilter(), filter)
+		for _, zf := range zr.File {
+			ok, err := scope(&zf.FileHeader)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+
+			entry := &mergeEntry{prefixes[path.Clean(apath.file)] + zf.Name, p, zf}
+			i, exists := index[entry.name]
+			switch {
+			case !exists:
+			case strings.HasSuffix(entry.name, "/"):
+				// directories are merged
+				continue
+			case o.conflict == conflictFirstWins:
+				continue
+			case o.conflict == conflictLastWins:
+				entries[i] = entry
+				continue
+			case o.conflict == conflictError:
+				return fmt.Errorf("%s: conflicts between %s and %s", entry.name, entries[i].source, p)
+			case o.conflict == conflictRename:
+				entry.name = uniqueName(entry.name, index)
+			}
+			index[entry.name] = len(entries)
+			entries = append(entries, entry)
+		}
+	}
+
+	return o.writeOutput("", func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		for _, entry := range entries {
+			if err := copyEntry(zw, entry.file, entry.name); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	})
+}
+
+// parsePrefixes parses SRC=PREFIX pairs, and completes the trailing slash of PREFIX.
+func parsePrefixes(values []string) (map[string]string, error) {
+	prefixes := make(map[string]string)
+	for _, v := range values {
+		i := strings.LastIndex(v, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid prefix %q (expected SRC=PREFIX)", v)
+		}
+		src, prefix := v[:i], strings.Trim(v[i+1:], "/")
+		if len(prefix) != 0 {
+			prefix += "/"
+		}
+		prefixes[path.Clean(src)] = prefix
+	}
+	return prefixes, nil
+}
+
+// uniqueName returns name with a number like "text (1).txt" not found in used.
+func uniqueName(name string, used map[string]int) string {
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		if _, ok := used[candidate]; !ok {
+			return candidate
+		}
+	}
+}
+
+// copyEntry copies the compressed data of zf as name without recompression.
+func copyEntry(zw *zip.Writer, zf *zip.File, name string) error {
+	if zf.Name == name {
+		return zw.CopyFile(zf)
+	}
+
+	dup := *zf
+	dup.Name = name
+	return zw.CopyFile(&dup)
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestMergeExecute(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+		bodies   map[string]string
+	}{
+		{
+			name: "first_wins",
+			args: []string{
+				"merge",
+			},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+			bodies: map[string]string{
+				"dir/text1.txt": "test 1",
+				"text1.txt":     "hello world",
+			},
+		},
+		{
+			name: "last_wins",
+			args: []string{
+				"merge",
+				"--conflict",
+				"last-wins",
+			},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+			bodies: map[string]string{
+				"dir/text1.txt": "test 3\r\ntest 2\r\ntest 1",
+				"text1.txt":     "hello3\r\nhello2\r\nhello1",
+			},
+		},
+		{
+			name: "rename",
+			args: []string{
+				"merge",
+				"--conflict",
+				"rename",
+				"--filter",
+				"**/*.txt",
+			},
+			contents: []string{
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"dir/text1 (1).txt",
+				"dir/text2 (1).txt",
+				"text1 (1).txt",
+			},
+			bodies: map[string]string{
+				"text1.txt":     "hello world",
+				"text1 (1).txt": "hello3\r\nhello2\r\nhello1",
+			},
+		},
+		{
+			name: "prefix",
+			args: []string{
+				"merge",
+				"--prefix",
+				"../testcase/test2.zip=second",
+			},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"second/dir/",
+				"second/dir/text1.txt",
+				"second/dir/text2.txt",
+				"second/text1.txt",
+			},
+			bodies: map[string]string{
+				"text1.txt":        "hello world",
+				"second/text1.txt": "hello3\r\nhello2\r\nhello1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			outname := filepath.Join(tmpdir, "merged.zip")
+			args := append(tt.args, "--out", outname, "../testcase/test.zip", "../testcase/test2.zip")
+			helperExecuteCommand(t, args)
+			helperRmCheckFileContents(t, outname, tt.contents)
+			helperConvertCheckFileContents(t, outname, tt.bodies)
+		})
+	}
+}
+
+func TestMergeRawCopy(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	outname := filepath.Join(tmpdir, "merged.zip")
+	helperExecuteCommand(t, []string{
+		"merge",
+		"--prefix",
+		"../testcase/test2.zip=second",
+		"--out",
+		outname,
+		"../testcase/test.zip",
+		"../testcase/test2.zip",
+	})
+
+	zr, err := zip.OpenReader(outname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	methods := map[string]uint16{
+		"text1.txt":        zip.Store,
+		"second/text1.txt": zip.Deflate,
+	}
+	for _, zf := range zr.File {
+		if method, ok := methods[zf.Name]; ok && zf.Method != method {
+			t.Fatalf("%s: method=%d, want %d", zf.Name, zf.Method, method)
+		}
+	}
+}
+
+func TestMergeConflictError(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	stderr := new(bytes.Buffer)
+	outname := filepath.Join(tmpdir, "merged.zip")
+
+	cmd := newRootCmd(new(bytes.Buffer), new(bytes.Buffer), stderr)
+	cmd.SetArgs([]string{
+		"merge",
+		"--conflict",
+		"error",
+		"--out",
+		outname,
+		"../testcase/test.zip",
+		"../testcase/test2.zip",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatal("conflict was not reported")
+	}
+	if _, err := os.Stat(outname); !os.IsNotExist(err) {
+		t.Fatalf("output was created: %v", err)
+	}
+}
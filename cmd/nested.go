@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hidez8891/zip"
+)
+
+const nestedSeparator = "!/"
+
+var archiveExtensions = []string{
+	".zip", ".jar", ".war", ".ear", ".apk", ".epub", ".cbz",
+}
+
+// archivePath addresses an archive like "outer.zip!/inner.zip!/dir/".
+// The last element after the separator limits the target entries
+// of the innermost archive; an empty one selects every entry.
+type archivePath struct {
+	file   string
+	nested []string
+	scope  string
+}
+
+func parseArchivePath(s string) archivePath {
+	parts := strings.Split(s, nestedSeparator)
+	if len(parts) == 1 {
+		return archivePath{file: s}
+	}
+	return archivePath{
+		file:   parts[0],
+		nested: parts[1 : len(parts)-1],
+		scope:  parts[len(parts)-1],
+	}
+}
+
+func (p archivePath) scopeFilter() pathFilter {
+	scope := p.scope
+	return func(h *zip.FileHeader) (bool, error) {
+		if len(scope) == 0 || h.Name == scope {
+			return true, nil
+		}
+		return strings.HasPrefix(h.Name, strings.TrimSuffix(scope, "/")+"/"), nil
+	}
+}
+
+func isArchiveName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func openNestedReader(r *zip.Reader, name string) (*zip.Reader, error) {
+	for _, zf := range r.File {
+		if zf.Name != name {
+			continue
+		}
+
+		rc, err := zf.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+
+		data, err := ioutil.ReadAll(rc)
+		if err != nil {
+			return nil, err
+		}
+		return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	}
+	return nil, fmt.Errorf("%s: not found nested archive", name)
+}
+
+func openNestedUpdater(zu *zip.Updater, name string) (*zip.Updater, error) {
+	r, err := zu.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewUpdater(bytes.NewReader(data), int64(len(data)))
+}
+
+func saveNestedUpdater(zu *zip.Updater, name string, inner *zip.Updater) error {
+	buf := new(bytes.Buffer)
+	if err := inner.SaveAs(buf); err != nil {
+		return err
+	}
+
+	w, err := zu.Update(name)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// openZipReader opens the innermost archive addressed by apath.
+func (o *baseCmd) openZipReader(apath archivePath) (*zip.Reader, io.Closer, error) {
+	file, err := o.openInput(apath.file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	zr, err := zip.NewReader(file, file.size)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	for _, name := range apath.nested {
+		zr, err = openNestedReader(zr, name)
+		if err != nil {
+			file.Close()
+			return nil, nil, err
+		}
+	}
+	return zr, file, nil
+}
+
+func (o *baseCmd) editNestedZip(zu *zip.Updater, nested []string, scope pathFilter, editor nestedZipEditor) (bool, error) {
+	if len(nested) == 0 {
+		return o.editArchive(zu, "", scope, editor)
+	}
+
+	snapshot := takeHeaderSnapshot(zu)
+	inner, err := openNestedUpdater(zu, nested[0])
+	if err != nil {
+		return false, err
+	}
+	defer inner.Close()
+
+	if ok, err := o.editNestedZip(inner, nested[1:], scope, editor); !ok {
+		return false, err
+	}
+	if err := saveNestedUpdater(zu, nested[0], inner); err != nil {
+		return false, err
+	}
+	// the enclosing archives keep their profiles like the edited one
+	return o.fixupArchive(zu, snapshot, true)
+}
+
+// editArchive applies editor to the entries of zu within scope, and with
+// the nested option also to every archive stored there.
+// prefix is the address of zu passed to editor.
+func (o *baseCmd) editArchive(zu *zip.Updater, prefix string, scope pathFilter, editor nestedZipEditor) (bool, error) {
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return false, err
+	}
+
+	snapshot := takeHeaderSnapshot(zu)
+	isModified, err := editor(zu, andFilter(scope, filter), prefix)
+	if err != nil {
+		return false, err
+	}
+	if !o.nested {
+		return o.fixupArchive(zu, snapshot, isModified)
+	}
+
+	for _, header := range zu.Files() {
+		if ok, _ := scope(header); !ok || !isArchiveName(header.Name) {
+			continue
+		}
+		name := header.Name
+
+		inner, err := openNestedUpdater(zu, name)
+		if err != nil {
+			return false, err
+		}
+
+		ok, err := o.editArchive(inner, prefix+name+nestedSeparator, archivePath{}.scopeFilter(), editor)
+		if err == nil && ok {
+			err = saveNestedUpdater(zu, name, inner)
+			isModified = true
+		}
+		inner.Close()
+		if err != nil {
+			return false, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return o.fixupArchive(zu, snapshot, isModified)
+}
+
+// fixupArchive applies the profile of the archive format to the modified zu.
+func (o *baseCmd) fixupArchive(zu *zip.Updater, snapshot headerSnapshot, isModified bool) (bool, error) {
+	if !isModified {
+		return false, nil
+	}
+	if err := o.applyProfile(zu, snapshot); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestNestedLsRender(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		output []string
+	}{
+		{
+			name: "inner_archive",
+			args: []string{
+				"ls",
+				"../testcase/nested.zip!/inner.zip!/",
+			},
+			output: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "inner_archive_scope",
+			args: []string{
+				"ls",
+				"../testcase/nested.zip!/inner.zip!/dir",
+			},
+			output: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+		{
+			name: "recursive",
+			args: []string{
+				"ls",
+				"--nested",
+				"--filter",
+				"**/text1.txt",
+				"../testcase/nested.zip",
+			},
+			output: []string{
+				"inner.zip!/dir/text1.txt",
+				"inner.zip!/text1.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name: "recursive_all",
+			args: []string{
+				"ls",
+				"--nested",
+				"../testcase/nested.zip",
+			},
+			output: []string{
+				"inner.zip",
+				"inner.zip!/dir/",
+				"inner.zip!/dir/text1.txt",
+				"inner.zip!/dir/text2.txt",
+				"inner.zip!/text1.txt",
+				"text1.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(tt.args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			if stderr.Len() != 0 {
+				t.Fatalf("error output: %q", stderr.String())
+			}
+
+			want := strings.Join(tt.output, "\n") + "\n"
+			if out := stdout.String(); out != want {
+				t.Fatalf("output=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestNestedRmExecuteOverwrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		args    []string
+		outer   []string
+		inner   []string
+	}{
+		{
+			name:    "inner_archive",
+			address: "!/inner.zip!/",
+			args: []string{
+				"rm",
+				"--overwrite",
+				"--filter",
+				"text1.txt",
+				"--show-progress=false",
+			},
+			outer: []string{
+				"inner.zip",
+				"text1.txt",
+			},
+			inner: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+		{
+			name:    "inner_archive_scope",
+			address: "!/inner.zip!/dir/",
+			args: []string{
+				"rm",
+				"--overwrite",
+				"--filter",
+				"**/*1.txt",
+				"--show-progress=false",
+			},
+			outer: []string{
+				"inner.zip",
+				"text1.txt",
+			},
+			inner: []string{
+				"dir/",
+				"dir/text2.txt",
+				"text1.txt",
+			},
+		},
+		{
+			name:    "recursive",
+			address: "",
+			args: []string{
+				"rm",
+				"--overwrite",
+				"--nested",
+				"--filter",
+				"text1.txt",
+				"--show-progress=false",
+			},
+			outer: []string{
+				"inner.zip",
+			},
+			inner: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/nested.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			helperExecuteCommand(t, append(tt.args, tmpname+tt.address))
+			helperRmCheckFileContents(t, tmpname, tt.outer)
+
+			stdout := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, new(bytes.Buffer))
+			cmd.SetArgs([]string{"ls", tmpname + "!/inner.zip!/"})
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Join(tt.inner, "\n") + "\n"
+			if out := stdout.String(); out != want {
+				t.Fatalf("inner contents=%q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestNestedRmEnclosingProfile(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// mimetype of the enclosing EPUB is neither the first nor stored
+	inner := helperArchiveData(t, []testEntry{
+		{name: "a.txt", body: "a"},
+		{name: "b.txt", body: "b"},
+	})
+	filename := filepath.Join(tmpdir, "book.epub")
+	helperCreateArchive(t, filename, []testEntry{
+		{name: epubContainerName, body: testEPUBContainer},
+		{name: epubMimetypeName, body: epubMimetype},
+		{name: "OEBPS/content.opf", body: testEPUBPackage},
+		{name: "inner.zip", body: string(inner)},
+	})
+
+	helperExecuteCommand(t, []string{"rm", "--overwrite", "--filter", "a.txt", "--show-progress=false", filename + "!/inner.zip!/"})
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	if zf := zr.File[0]; zf.Name != epubMimetypeName || zf.Method != zip.Store {
+		t.Fatalf("first entry=%s method=%d, want stored %s", zf.Name, zf.Method, epubMimetypeName)
+	}
+}
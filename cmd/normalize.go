@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"compress/flate"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newNormalizeCmd(params *cmdParams) *cobra.Command {
+	normalizecmd := &normalize{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "normalize [filepath...]",
+		Short: "Rewrite archive reproducibly",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			normalizecmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().IntVar(&normalizecmd.level, "level", 6, "compression level (0-9)")
+	cmd.Flags().StringVar(&normalizecmd.time, "time", "", "modification time of entries (default SOURCE_DATE_EPOCH or 1980-01-01 00:00:00 UTC)")
+	normalizecmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+// normalize rewrites every entry of archives in the same way, so that
+// archives of the same contents become byte-identical.
+type normalize struct {
+	*baseCmd
+	pexe    *toolParallelCmd
+	level   int
+	time    string
+	modTime time.Time
+}
+
+func (o *normalize) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.validate(paths); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *normalize) validate(paths []string) error {
+	if o.level < flate.NoCompression || o.level > flate.BestCompression {
+		return fmt.Errorf("compression level must be 0-9")
+	}
+
+	for _, filepath := range paths {
+		if strings.Contains(filepath, nestedSeparator) {
+			return fmt.Errorf("%s: nested archive cannot be normalized", filepath)
+		}
+	}
+
+	t, err := normalizedTime(o.time)
+	if err != nil {
+		return err
+	}
+	o.modTime = t
+	return nil
+}
+
+// normalizedTime returns s, SOURCE_DATE_EPOCH or the MS-DOS epoch in this order.
+func normalizedTime(s string) (time.Time, error) {
+	if len(s) != 0 {
+		t, err := parseTime(s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// keep the wall clock as the MS-DOS time has no time zone
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), nil
+	}
+
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); len(epoch) != 0 {
+		v, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q", epoch)
+		}
+		return time.Unix(v, 0).UTC(), nil
+	}
+	return time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (o *normalize) execute(filepath string) error {
+	file, err := o.openInput(filepath)
+	if err != nil {
+		return err
+	}
+	defer close(file)
+
+	zr, err := zip.NewReader(file, file.size)
+	if err != nil {
+		return err
+	}
+
+	profile, err := o.inputProfile(file)
+	if err != nil {
+		return err
+	}
+	files := sortFiles(zr.File, profile)
+	stored := make(map[string]bool)
+	if profile != nil {
+		for _, name := range profile.stored {
+			stored[name] = true
+		}
+	}
+
+	return o.writeOutput(filepath, func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, o.level)
+		})
+
+		for _, zf := range files {
+			if err := o.writeEntry(zw, zf, stored[zf.Name]); err != nil {
+				return fmt.Errorf("%s: %v", zf.Name, err)
+			}
+		}
+		return zw.Close()
+	}, file)
+}
+
+// inputProfile returns the profile applied to the archive of file, or nil.
+func (o *normalize) inputProfile(file *inputFile) (*archiveProfile, error) {
+	zu, err := zip.NewUpdater(file, file.size)
+	if err != nil {
+		return nil, err
+	}
+	defer zu.Close()
+
+	return o.selectProfile(zu)
+}
+
+// sortFiles sorts files by name after the entries which profile
+// requires at the top.
+func sortFiles(files []*zip.File, profile *archiveProfile) []*zip.File {
+	rank := make(map[string]int)
+	if profile != nil {
+		for i, name := range profile.leading {
+			rank[name] = i - len(profile.leading)
+		}
+	}
+
+	sorted := make([]*zip.File, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		ri, rj := rank[sorted[i].Name], rank[sorted[j].Name]
+		if ri != rj {
+			return ri < rj
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// writeEntry rewrites zf with the fixed metadata. Files are deflated
+// at the level whatever the method of zf is, except the entries which
+// the profile requires stored.
+// The time is set only to the MS-DOS fields not to add the extra field.
+func (o *normalize) writeEntry(zw *zip.Writer, zf *zip.File, store bool) error {
+	if zf.Method != zip.Store && zf.Method != zip.Deflate {
+		return o.copyEntry(zw, zf)
+	}
+
+	isDir := strings.HasSuffix(zf.Name, "/")
+	fh := &zip.FileHeader{
+		Name:    zf.Name,
+		NonUTF8: zf.NonUTF8,
+		Method:  zip.Deflate,
+	}
+	if isDir || store {
+		fh.Method = zip.Store
+	}
+	fh.ModifiedDate, fh.ModifiedTime = timeToMsDosTime(o.modTime)
+	fh.SetMode(normalizedMode(zf.Mode()))
+
+	w, err := zw.CreateHeader(fh)
+	if err != nil {
+		return err
+	}
+	if isDir {
+		return nil
+	}
+
+	r, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// copyEntry copies zf which cannot be recompressed as it is,
+// with the metadata fixed in the same way as writeEntry.
+func (o *normalize) copyEntry(zw *zip.Writer, zf *zip.File) error {
+	dup := *zf
+	dup.Comment = ""
+	dup.Modified = time.Time{}
+	dup.ModifiedDate, dup.ModifiedTime = timeToMsDosTime(o.modTime)
+	dup.SetMode(normalizedMode(zf.Mode()))
+	for _, id := range []uint16{ntfsExtraID, extTimeExtraID, unixIDExtraID, unixNewExtraID, alignExtraID} {
+		dup.Extra = removeExtraField(dup.Extra, id)
+	}
+	return zw.CopyFile(&dup)
+}
+
+// normalizedMode keeps only the type and whether mode is executable.
+func normalizedMode(mode os.FileMode) os.FileMode {
+	switch {
+	case mode.IsDir():
+		return os.ModeDir | 0755
+	case mode&os.ModeSymlink != 0:
+		return os.ModeSymlink | 0777
+	case mode&0111 != 0:
+		return 0755
+	default:
+		return 0644
+	}
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestNormalizeReproducible(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	// same contents as test.zip in another order, method, time and comment
+	other := filepath.Join(tmpdir, "other.zip")
+	now := time.Now()
+	var entries []testEntry
+	for _, e := range []struct{ name, body string }{
+		{"text1.txt", "hello world"},
+		{"dir/text2.txt", "test 2"},
+		{"dir/", ""},
+		{"dir/text1.txt", "test 1"},
+	} {
+		entries = append(entries, testEntry{
+			name:     e.name,
+			body:     e.body,
+			stored:   true,
+			mode:     0600,
+			modified: now,
+			comment:  "comment",
+		})
+	}
+	helperCreateArchive(t, other, entries)
+	helperExecuteCommand(t, []string{"comment", "--overwrite", "--set", "archive comment", "--show-progress=false", other})
+
+	out1 := filepath.Join(tmpdir, "out1.zip")
+	out2 := filepath.Join(tmpdir, "out2.zip")
+	helperExecuteCommand(t, []string{"normalize", "--out", out1, "--show-progress=false", "../testcase/test.zip"})
+	helperExecuteCommand(t, []string{"normalize", "--out", out2, "--show-progress=false", other})
+
+	data1, err := ioutil.ReadFile(out1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data2, err := ioutil.ReadFile(out2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data1, data2) {
+		t.Fatal("normalized archives are different")
+	}
+
+	helperRmCheckFileContents(t, out1, []string{
+		"dir/",
+		"dir/text1.txt",
+		"dir/text2.txt",
+		"text1.txt",
+	})
+	helperConvertCheckFileContents(t, out1, map[string]string{
+		"dir/text1.txt": "test 1",
+		"text1.txt":     "hello world",
+	})
+
+	zr, err := zip.OpenReader(out1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	if len(zr.Comment) != 0 {
+		t.Fatalf("archive comment=%q", zr.Comment)
+	}
+	for _, zf := range zr.File {
+		if len(zf.Extra) != 0 || len(zf.Comment) != 0 {
+			t.Fatalf("%s: extra=%v comment=%q", zf.Name, zf.Extra, zf.Comment)
+		}
+		want, method := os.FileMode(0644), uint16(zip.Deflate)
+		if zf.Name == "dir/" {
+			want, method = os.ModeDir|0755, zip.Store
+		}
+		if zf.Method != method {
+			t.Fatalf("%s: method=%d, want %d", zf.Name, zf.Method, method)
+		}
+		if zf.Mode() != want {
+			t.Fatalf("%s: mode=%v, want %v", zf.Name, zf.Mode(), want)
+		}
+		if mtime := zf.ModTime(); !mtime.Equal(time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("%s: mtime=%v", zf.Name, mtime)
+		}
+	}
+}
+
+func TestNormalizeMethod(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	var outputs [][]byte
+	for i, stored := range []bool{true, false} {
+		src := filepath.Join(tmpdir, "src"+strconv.Itoa(i)+".zip")
+		helperCreateArchive(t, src, []testEntry{
+			{name: "dir/", stored: stored},
+			{name: "dir/text1.txt", body: "test 1", stored: stored},
+			{name: "text1.txt", body: "hello world", stored: stored},
+		})
+
+		out := filepath.Join(tmpdir, "out"+strconv.Itoa(i)+".zip")
+		helperExecuteCommand(t, []string{"normalize", "--out", out, "--show-progress=false", src})
+		data, err := ioutil.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, data)
+	}
+
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Fatal("normalized archives of stored and deflated entries are different")
+	}
+}
+
+func TestNormalizeEPUBMimetype(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "book.epub")
+	helperCreateEPUB(t, filename)
+
+	outname := filepath.Join(tmpdir, "out.epub")
+	helperExecuteCommand(t, []string{"normalize", "--out", outname, "--show-progress=false", filename})
+
+	zr, err := zip.OpenReader(outname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	for i, zf := range zr.File {
+		method := uint16(zip.Deflate)
+		if zf.Name == epubMimetypeName {
+			if i != 0 {
+				t.Fatalf("%s is at %d, want the top", zf.Name, i)
+			}
+			method = zip.Store
+		}
+		if zf.Method != method {
+			t.Fatalf("%s: method=%d, want %d", zf.Name, zf.Method, method)
+		}
+	}
+}
+
+func TestNormalizeSourceDateEpoch(t *testing.T) {
+	tmpname, err := copyTempFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpname)
+
+	os.Setenv("SOURCE_DATE_EPOCH", "1600000000")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	helperExecuteCommand(t, []string{"normalize", "--overwrite", "--show-progress=false", tmpname})
+
+	zr, err := zip.OpenReader(tmpname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	want := time.Unix(1600000000, 0).UTC()
+	for _, zf := range zr.File {
+		if mtime := zf.ModTime(); !mtime.Equal(want) {
+			t.Fatalf("%s: mtime=%v, want %v", zf.Name, mtime, want)
+		}
+	}
+}
+
+func TestNormalizeProfileOrder(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "app.jar")
+	helperCreateJAR(t, filename)
+
+	outname := filepath.Join(tmpdir, "out.jar")
+	helperExecuteCommand(t, []string{"normalize", "--out", outname, "--show-progress=false", filename})
+
+	helperRmCheckFileContents(t, outname, []string{
+		"META-INF/",
+		"META-INF/MANIFEST.MF",
+		"META-INF/CERT.RSA",
+		"META-INF/CERT.SF",
+		"com/A.class",
+		"com/B.class",
+	})
+}
@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hidez8891/zip"
+)
+
+const (
+	ooxmlProfileName      = "ooxml"
+	ooxmlContentTypesName = "[Content_Types].xml"
+	ooxmlRelsDir          = "_rels/"
+	ooxmlRelsExt          = ".rels"
+	ooxmlExternalMode     = "External"
+)
+
+// ooxmlProfile keeps the parts of Office Open XML documents consistent
+// with the content types and the relationships.
+var ooxmlProfile = &archiveProfile{
+	name:   ooxmlProfileName,
+	detect: isOOXML,
+	fixup:  (*cmdParams).fixupOOXML,
+}
+
+type ooxmlContentTypes struct {
+	Defaults []struct {
+		Extension string `xml:",attr"`
+	} `xml:"Default"`
+	Overrides []struct {
+		PartName string `xml:",attr"`
+	} `xml:"Override"`
+}
+
+type ooxmlRelationships struct {
+	Relationships []struct {
+		Target     string `xml:",attr"`
+		TargetMode string `xml:",attr"`
+	} `xml:"Relationship"`
+}
+
+var (
+	ooxmlOverridePattern = regexp.MustCompile(`<Override\s[^>]*>`)
+	ooxmlPartNamePattern = regexp.MustCompile(`(\sPartName\s*=\s*)("[^"]*"|'[^']*')`)
+)
+
+func isOOXML(zu *zip.Updater) bool {
+	return findHeader(zu, ooxmlContentTypesName) != nil
+}
+
+func (o *cmdParams) fixupOOXML(zu *zip.Updater, changes *entryChanges, warn func(string, ...interface{})) error {
+	if findHeader(zu, ooxmlContentTypesName) == nil {
+		warn("%s is not found", ooxmlContentTypesName)
+		return nil
+	}
+
+	if len(changes.renamed) != 0 {
+		if err := renameOOXMLOverrides(zu, changes.renamed); err != nil {
+			return err
+		}
+	}
+
+	types, err := readOOXMLContentTypes(zu)
+	if err != nil {
+		warn("%v", err)
+		return nil
+	}
+	parts := append([]string{}, changes.added...)
+	for _, newname := range changes.renamed {
+		parts = append(parts, newname)
+	}
+	for _, name := range parts {
+		if !strings.HasSuffix(name, "/") && !types.covers(name) {
+			warn("%s has no content type in %s", name, ooxmlContentTypesName)
+		}
+	}
+
+	lost := append([]string{}, changes.removed...)
+	for oldname := range changes.renamed {
+		lost = append(lost, oldname)
+	}
+	if len(lost) == 0 {
+		return nil
+	}
+
+	for _, header := range zu.Files() {
+		source, ok := ooxmlRelsSource(header.Name)
+		if !ok {
+			continue
+		}
+
+		targets, err := ooxmlRelsTargets(zu, header.Name, source)
+		if err != nil {
+			warn("%s: %v", header.Name, err)
+			continue
+		}
+		for _, name := range lost {
+			if targets[name] && findHeader(zu, name) == nil {
+				warn("%s is still referenced in %s", name, header.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// covers reports whether the content type of the part name is declared.
+func (t *ooxmlContentTypes) covers(name string) bool {
+	for _, override := range t.Overrides {
+		if strings.EqualFold(strings.TrimPrefix(override.PartName, "/"), name) {
+			return true
+		}
+	}
+	ext := strings.TrimPrefix(path.Ext(name), ".")
+	for _, def := range t.Defaults {
+		if len(ext) != 0 && strings.EqualFold(def.Extension, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func readOOXMLContentTypes(zu *zip.Updater) (*ooxmlContentTypes, error) {
+	data, err := readUpdaterEntry(zu, ooxmlContentTypesName)
+	if err != nil {
+		return nil, err
+	}
+
+	var types ooxmlContentTypes
+	if err := xml.Unmarshal(data, &types); err != nil {
+		return nil, fmt.Errorf("%s: %v", ooxmlContentTypesName, err)
+	}
+	return &types, nil
+}
+
+// renameOOXMLOverrides rewrites the part names of the overrides of the renamed parts.
+// The document is edited as text to keep the rest of it as it is.
+func renameOOXMLOverrides(zu *zip.Updater, renamed map[string]string) error {
+	data, err := readUpdaterEntry(zu, ooxmlContentTypesName)
+	if err != nil {
+		return err
+	}
+
+	partNames := make(map[string]string, len(renamed))
+	for oldname, newname := range renamed {
+		partNames[strings.ToLower("/"+oldname)] = "/" + newname
+	}
+
+	isModified := false
+	result := ooxmlOverridePattern.ReplaceAllFunc(data, func(elem []byte) []byte {
+		return ooxmlPartNamePattern.ReplaceAllFunc(elem, func(attr []byte) []byte {
+			m := ooxmlPartNamePattern.FindSubmatch(attr)
+			value := string(m[2][1 : len(m[2])-1])
+			newname, ok := partNames[strings.ToLower(unescapeXMLText(value))]
+			if !ok {
+				return attr
+			}
+
+			isModified = true
+			buf := new(bytes.Buffer)
+			xml.EscapeText(buf, []byte(newname))
+			return []byte(string(m[1]) + `"` + buf.String() + `"`)
+		})
+	})
+	if !isModified {
+		return nil
+	}
+
+	return writeUpdaterEntry(zu, ooxmlContentTypesName, result)
+}
+
+func unescapeXMLText(s string) string {
+	var text string
+	if err := xml.Unmarshal([]byte("<v>"+s+"</v>"), &text); err != nil {
+		return s
+	}
+	return text
+}
+
+// ooxmlRelsSource returns the source part of the relationship part name,
+// or "" for the package relationships.
+func ooxmlRelsSource(name string) (string, bool) {
+	dir, base := path.Split(name)
+	if !strings.HasSuffix(dir, ooxmlRelsDir) || !strings.HasSuffix(base, ooxmlRelsExt) {
+		return "", false
+	}
+	return strings.TrimSuffix(dir, ooxmlRelsDir) + strings.TrimSuffix(base, ooxmlRelsExt), true
+}
+
+// ooxmlRelsTargets returns the part names which the relationship part refers to.
+func ooxmlRelsTargets(zu *zip.Updater, rels, source string) (map[string]bool, error) {
+	data, err := readUpdaterEntry(zu, rels)
+	if err != nil {
+		return nil, err
+	}
+
+	var relationships ooxmlRelationships
+	if err := xml.Unmarshal(data, &relationships); err != nil {
+		return nil, err
+	}
+
+	targets := make(map[string]bool)
+	for _, rel := range relationships.Relationships {
+		if rel.TargetMode == ooxmlExternalMode {
+			continue
+		}
+		if name, ok := resolveHref(source, rel.Target); ok {
+			targets[name] = true
+		}
+	}
+	return targets, nil
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testOOXMLContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Default Extension="png" ContentType="image/png"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+	`</Types>`
+
+const testOOXMLRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`</Relationships>`
+
+const testOOXMLDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/image1.png"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="media/image1.png" TargetMode="External"/>` +
+	`</Relationships>`
+
+const testOOXMLCore = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+	`<dc:title>Report</dc:title><dc:creator>Alice</dc:creator><cp:lastModifiedBy>Bob</cp:lastModifiedBy>` +
+	`</cp:coreProperties>`
+
+const testOOXMLApp = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+	`<Application>Microsoft Office Word</Application><Manager>Carol</Manager><Company>Example Inc.</Company>` +
+	`</Properties>`
+
+func helperCreateDOCX(t *testing.T, filename string) {
+	t.Helper()
+
+	helperCreateArchive(t, filename, []testEntry{
+		{name: "[Content_Types].xml", body: testOOXMLContentTypes},
+		{name: "_rels/.rels", body: testOOXMLRels},
+		{name: "word/document.xml", body: "<w:document/>"},
+		{name: "word/_rels/document.xml.rels", body: testOOXMLDocumentRels},
+		{name: "word/media/image1.png", body: "png"},
+		{name: "docProps/core.xml", body: testOOXMLCore},
+		{name: "docProps/app.xml", body: testOOXMLApp},
+	})
+}
+
+func TestOOXMLProfile(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		warnings     []string
+		contentTypes string
+	}{
+		{
+			name: "rm_referenced",
+			args: []string{"rm", "--overwrite", "--filter", "word/media/*", "--show-progress=false"},
+			warnings: []string{
+				"warning: ooxml: word/media/image1.png is still referenced in word/_rels/document.xml.rels",
+			},
+			contentTypes: testOOXMLContentTypes,
+		},
+		{
+			name: "rename_override",
+			args: []string{"rename", "--overwrite", "--from", "word/document.xml", "--to", "word/main.xml", "--show-progress=false"},
+			warnings: []string{
+				"warning: ooxml: word/document.xml is still referenced in _rels/.rels",
+			},
+			contentTypes: strings.Replace(testOOXMLContentTypes, `"/word/document.xml"`, `"/word/main.xml"`, 1),
+		},
+		{
+			name: "rename_no_content_type",
+			args: []string{"rename", "--overwrite", "--from", "image1.png", "--to", "image1.gif", "--show-progress=false"},
+			warnings: []string{
+				"warning: ooxml: word/media/image1.gif has no content type in [Content_Types].xml",
+				"warning: ooxml: word/media/image1.png is still referenced in word/_rels/document.xml.rels",
+			},
+			contentTypes: testOOXMLContentTypes,
+		},
+		{
+			name:         "rm_unreferenced",
+			args:         []string{"rm", "--overwrite", "--filter", "docProps/app.xml", "--show-progress=false"},
+			contentTypes: testOOXMLContentTypes,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			filename := filepath.Join(tmpdir, "report.docx")
+			helperCreateDOCX(t, filename)
+
+			stdout := new(bytes.Buffer)
+			stderr := new(bytes.Buffer)
+			cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+			cmd.SetArgs(append(tt.args, filename))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			want := ""
+			if len(tt.warnings) != 0 {
+				want = strings.Join(tt.warnings, "\n") + "\n"
+			}
+			if stderr.String() != want {
+				t.Fatalf("error output=%q, want %q", stderr.String(), want)
+			}
+
+			if got := helperReadEntry(t, filename, ooxmlContentTypesName); got != tt.contentTypes {
+				t.Fatalf("content types=%q, want %q", got, tt.contentTypes)
+			}
+		})
+	}
+}
+
+func TestScrubMetadata(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	filename := filepath.Join(tmpdir, "report.docx")
+	helperCreateDOCX(t, filename)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"scrub-metadata", "--overwrite", "--show-progress=false", filename})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if stderr.Len() != 0 {
+		t.Fatalf("error output=%q", stderr.String())
+	}
+
+	core := strings.Replace(testOOXMLCore, "Alice", "", 1)
+	core = strings.Replace(core, "Bob", "", 1)
+	if got := helperReadEntry(t, filename, ooxmlCorePropsName); got != core {
+		t.Fatalf("core properties=%q, want %q", got, core)
+	}
+
+	app := strings.Replace(testOOXMLApp, "Carol", "", 1)
+	app = strings.Replace(app, "Example Inc.", "", 1)
+	if got := helperReadEntry(t, filename, ooxmlAppPropsName); got != app {
+		t.Fatalf("app properties=%q, want %q", got, app)
+	}
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	path "path/filepath"
+	"strings"
+)
+
+func isOutputTemplate(s string) bool {
+	return strings.Contains(s, "{") && strings.Contains(s, "}")
+}
+
+// expandOutputTemplate replaces {dir}, {name}, {stem} and {ext}
+// with the parts of filepath.
+func expandOutputTemplate(tmpl, filepath string) string {
+	name := path.Base(filepath)
+	ext := path.Ext(name)
+
+	r := strings.NewReplacer(
+		"{dir}", path.Dir(filepath),
+		"{name}", name,
+		"{stem}", strings.TrimSuffix(name, ext),
+		"{ext}", ext,
+	)
+	return path.Clean(r.Replace(tmpl))
+}
+
+// resolveOutputs maps each input file to its output file for the
+// --out-dir and --out template modes.
+func (o *cmdParams) resolveOutputs(paths []string) (map[string]string, error) {
+	files := make([]string, 0, len(paths))
+	for _, p := range paths {
+		file := parseArchivePath(p).file
+		if file == stdioPath {
+			return nil, fmt.Errorf("standard input cannot be used with an output directory or template")
+		}
+		files = append(files, file)
+	}
+
+	base := ""
+	if len(o.outDir) != 0 {
+		var err error
+		if base, err = commonDir(files); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs := make(map[string]string)
+	sources := make(map[string]string)
+	for _, file := range files {
+		var out string
+		if len(o.outDir) != 0 {
+			abs, err := path.Abs(file)
+			if err != nil {
+				return nil, err
+			}
+			rel, err := path.Rel(base, abs)
+			if err != nil {
+				return nil, err
+			}
+			out = path.Join(o.outDir, rel)
+		} else {
+			out = expandOutputTemplate(o.outFilename, file)
+		}
+
+		key, err := path.Abs(out)
+		if err != nil {
+			return nil, err
+		}
+		if src, ok := sources[key]; ok && src != file {
+			return nil, fmt.Errorf("output %s is shared by %s and %s", out, src, file)
+		}
+		if abs, _ := path.Abs(file); abs == key {
+			return nil, fmt.Errorf("output %s is the same as the input", out)
+		}
+		sources[key] = file
+		outputs[file] = out
+	}
+	return outputs, nil
+}
+
+func (o *cmdParams) outputPath(filepath string) string {
+	if out, ok := o.outputs[filepath]; ok {
+		return out
+	}
+	return o.outFilename
+}
+
+func createOutputFile(filename string) (*os.File, error) {
+	if dir := path.Dir(filename); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0666)
+}
+
+func commonDir(files []string) (string, error) {
+	common := ""
+	for i, file := range files {
+		abs, err := path.Abs(file)
+		if err != nil {
+			return "", err
+		}
+		dir := path.Dir(abs)
+		if i == 0 {
+			common = dir
+			continue
+		}
+		for !isSubPath(common, dir) {
+			parent := path.Dir(common)
+			if parent == common {
+				// no common root, like the paths of different volumes
+				return "", fmt.Errorf("%s and %s have no common directory", files[0], file)
+			}
+			common = parent
+		}
+	}
+	return common, nil
+}
+
+func isSubPath(base, target string) bool {
+	rel, err := path.Rel(base, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(path.Separator))
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputDirectory(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src1 := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "in", "a.zip"))
+	src2 := helperCopyFileTo(t, "../testcase/test2.zip", filepath.Join(tmpdir, "in", "sub", "b.zip"))
+	outdir := filepath.Join(tmpdir, "out")
+
+	helperExecuteCommand(t, []string{
+		"rm",
+		"--out-dir",
+		outdir,
+		"--filter",
+		"dir/*",
+		"--show-progress=false",
+		src1,
+		src2,
+	})
+
+	contents := []string{
+		"dir/",
+		"text1.txt",
+	}
+	helperRmCheckFileContents(t, filepath.Join(outdir, "a.zip"), contents)
+	helperRmCheckFileContents(t, filepath.Join(outdir, "sub", "b.zip"), contents)
+
+	original := []string{
+		"dir/",
+		"dir/text1.txt",
+		"dir/text2.txt",
+		"text1.txt",
+	}
+	helperRmCheckFileContents(t, src1, original)
+	helperRmCheckFileContents(t, src2, original)
+}
+
+func TestOutputTemplate(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src1 := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "a.zip"))
+	src2 := helperCopyFileTo(t, "../testcase/test2.zip", filepath.Join(tmpdir, "b.zip"))
+
+	helperExecuteCommand(t, []string{
+		"rm",
+		"--out",
+		"{dir}/{stem}.clean{ext}",
+		"--filter",
+		"text1.txt",
+		"--show-progress=false",
+		src1,
+		src2,
+	})
+
+	contents := []string{
+		"dir/",
+		"dir/text1.txt",
+		"dir/text2.txt",
+	}
+	helperRmCheckFileContents(t, filepath.Join(tmpdir, "a.clean.zip"), contents)
+	helperRmCheckFileContents(t, filepath.Join(tmpdir, "b.clean.zip"), contents)
+}
+
+func TestOutputTemplateCollision(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src1 := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "a.zip"))
+	src2 := helperCopyFileTo(t, "../testcase/test2.zip", filepath.Join(tmpdir, "b.zip"))
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"rm",
+		"--out",
+		"{dir}/out.zip",
+		"--show-progress=false",
+		src1,
+		src2,
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatalf("output collision is not detected")
+	}
+	if _, err := os.Stat(filepath.Join(tmpdir, "out.zip")); !os.IsNotExist(err) {
+		t.Fatalf("output file is created")
+	}
+}
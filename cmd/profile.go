@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/hidez8891/zip"
+)
+
+const (
+	profileAuto = "auto"
+	profileNone = "none"
+)
+
+// archiveProfile keeps the rules of an archive format like EPUB
+// after the entries of the archive were edited.
+type archiveProfile struct {
+	name string
+
+	// leading are the entries which the format requires at the top in this order.
+	leading []string
+
+	// stored are the entries which the format requires stored without compression.
+	stored []string
+
+	// detect reports whether zu is an archive of the format.
+	detect func(zu *zip.Updater) bool
+
+	// fixup corrects zu after the changes with the options of o.
+	// The problems which cannot be corrected are reported by warn.
+	fixup func(o *cmdParams, zu *zip.Updater, changes *entryChanges, warn func(string, ...interface{})) error
+}
+
+var archiveProfiles = []*archiveProfile{
+	epubProfile,
+	jarProfile,
+	ooxmlProfile,
+	cbzProfile,
+}
+
+// entryChanges is the difference of the entries made by an edit.
+type entryChanges struct {
+	added    []string
+	removed  []string
+	modified []string
+	renamed  map[string]string // old name to new name
+}
+
+// headerSnapshot records the headers of an archive before an edit.
+// The updater keeps the header of a renamed or rewritten entry,
+// so the rewritten contents are detected by the checksum.
+type headerSnapshot map[*zip.FileHeader]snapshotEntry
+
+type snapshotEntry struct {
+	name  string
+	crc32 uint32
+	size  uint64
+}
+
+func takeHeaderSnapshot(zu *zip.Updater) headerSnapshot {
+	snapshot := make(headerSnapshot)
+	for _, header := range zu.Files() {
+		snapshot[header] = snapshotEntry{header.Name, header.CRC32, header.UncompressedSize64}
+	}
+	return snapshot
+}
+
+func (s headerSnapshot) changes(zu *zip.Updater) *entryChanges {
+	changes := &entryChanges{renamed: make(map[string]string)}
+
+	current := make(map[*zip.FileHeader]bool)
+	names := make(map[string]bool)
+	for _, header := range zu.Files() {
+		current[header] = true
+		names[header.Name] = true
+	}
+
+	oldnames := make(map[string]bool)
+	for header, entry := range s {
+		name := entry.name
+		oldnames[name] = true
+		if current[header] && (header.CRC32 != entry.crc32 || header.UncompressedSize64 != entry.size) {
+			changes.modified = append(changes.modified, header.Name)
+		}
+		switch {
+		case current[header] && header.Name != name:
+			changes.renamed[name] = header.Name
+		case current[header]:
+		case names[name]:
+			changes.modified = append(changes.modified, name)
+		default:
+			changes.removed = append(changes.removed, name)
+		}
+	}
+
+	for _, header := range zu.Files() {
+		if _, ok := s[header]; !ok && !oldnames[header.Name] {
+			changes.added = append(changes.added, header.Name)
+		}
+	}
+	sort.Strings(changes.removed)
+	sort.Strings(changes.modified)
+	return changes
+}
+
+// selectProfile returns the profile applied to zu, or nil.
+func (o *cmdParams) selectProfile(zu *zip.Updater) (*archiveProfile, error) {
+	if o.epub {
+		return epubProfile, nil
+	}
+
+	switch o.profile {
+	case profileNone:
+		return nil, nil
+	case profileAuto, "":
+		for _, profile := range archiveProfiles {
+			if profile.detect(zu) {
+				return profile, nil
+			}
+		}
+		return nil, nil
+	}
+
+	for _, profile := range archiveProfiles {
+		if profile.name == o.profile {
+			return profile, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown profile: %s", o.profile)
+}
+
+// validateProfileFlag checks the options of the profiles before any archive is written.
+func (o *cmdParams) validateProfileFlag() error {
+	switch o.signature {
+	case signatureWarn, signatureStrip:
+		return nil
+	default:
+		return fmt.Errorf("unknown JAR signature handling: %s", o.signature)
+	}
+}
+
+// applyProfile corrects zu edited from snapshot by the profile of the archive format.
+func (o *cmdParams) applyProfile(zu *zip.Updater, snapshot headerSnapshot) error {
+	profile, err := o.selectProfile(zu)
+	if err != nil || profile == nil {
+		return err
+	}
+
+	changes := snapshot.changes(zu)
+	warn := func(format string, args ...interface{}) {
+		fmt.Fprintf(o.stderr, "warning: %s: %s\n", profile.name, fmt.Sprintf(format, args...))
+	}
+	return profile.fixup(o, zu, changes, warn)
+}
+
+// profiledSave wraps save of an archive written without the updater,
+// so that the archive also keeps the rules of the profile.
+func (o *cmdParams) profiledSave(save func(io.Writer) error) func(io.Writer) error {
+	return func(w io.Writer) error {
+		buf := new(bytes.Buffer)
+		if err := save(buf); err != nil {
+			return err
+		}
+
+		zu, err := zip.NewUpdater(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			return err
+		}
+		defer zu.Close()
+
+		profile, err := o.selectProfile(zu)
+		if err != nil {
+			return err
+		}
+		if profile == nil {
+			_, err := w.Write(buf.Bytes())
+			return err
+		}
+
+		// the archive is new, so the profile corrects it without changes
+		if err := o.applyProfile(zu, takeHeaderSnapshot(zu)); err != nil {
+			return fmt.Errorf("%s profile cannot be kept: %v", profile.name, err)
+		}
+		return zu.SaveAs(w)
+	}
+}
+
+func readUpdaterEntry(zu *zip.Updater, name string) ([]byte, error) {
+	r, err := zu.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	defer r.Close()
+
+	return ioutil.ReadAll(r)
+}
+
+// writeUpdaterEntry creates or rewrites the entry name of zu with data.
+func writeUpdaterEntry(zu *zip.Updater, name string, data []byte) error {
+	var w io.WriteCloser
+	var err error
+	if findHeader(zu, name) == nil {
+		w, err = zu.Create(name)
+	} else {
+		w, err = zu.Update(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -11,13 +11,13 @@ import (
 func newRenameCmd(params *cmdParams) *cobra.Command {
 	renamecmd := &rename{
 		baseCmd: &baseCmd{params},
-		pexe:    &toolParallelCmd{writer: params.stdout},
+		pexe:    &toolParallelCmd{params: params},
 	}
 
 	var cmd = &cobra.Command{
 		Use:   "rename [filepath...]",
 		Short: "Rename file contents",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  params.requireInputs,
 		Run: func(cmd *cobra.Command, args []string) {
 			renamecmd.run(cmd, args)
 		},
@@ -37,7 +37,7 @@ type rename struct {
 }
 
 func (o *rename) run(cmd *cobra.Command, args []string) {
-	paths, err := expandFilePath(args)
+	paths, err := o.expandFilePath(args)
 	if err != nil {
 		fmt.Fprintln(o.stderr, err)
 		return
@@ -64,15 +64,10 @@ func (o *rename) run(cmd *cobra.Command, args []string) {
 }
 
 func (o *rename) execute(filepath string) error {
-	return o.editZipFile(filepath, func(zu *zip.Updater) (bool, error) {
-		filter, err := o.generatePathFilter()
-		if err != nil {
-			return false, err
-		}
-
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
 		isModified := false
 		for _, header := range zu.Files() {
-			ok, err := filter(header.Name)
+			ok, err := filter(header)
 			if err != nil {
 				return false, err
 			}
@@ -10,13 +10,13 @@ import (
 func newRmCmd(params *cmdParams) *cobra.Command {
 	rmcmd := &rm{
 		baseCmd: &baseCmd{params},
-		pexe:    &toolParallelCmd{writer: params.stdout},
+		pexe:    &toolParallelCmd{params: params},
 	}
 
 	var cmd = &cobra.Command{
 		Use:   "rm [filepath...]",
 		Short: "Remove file",
-		Args:  cobra.MinimumNArgs(1),
+		Args:  params.requireInputs,
 		Run: func(cmd *cobra.Command, args []string) {
 			rmcmd.run(cmd, args)
 		},
@@ -32,7 +32,7 @@ type rm struct {
 }
 
 func (o *rm) run(cmd *cobra.Command, args []string) {
-	paths, err := expandFilePath(args)
+	paths, err := o.expandFilePath(args)
 	if err != nil {
 		fmt.Fprintln(o.stderr, err)
 		return
@@ -59,28 +59,32 @@ func (o *rm) run(cmd *cobra.Command, args []string) {
 }
 
 func (o *rm) execute(filepath string) error {
-	return o.editZipFile(filepath, func(zu *zip.Updater) (bool, error) {
-		filter, err := o.generatePathFilter()
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		removed, err := removeEntries(zu, filter)
 		if err != nil {
 			return false, err
 		}
+		return len(removed) != 0, nil
+	})
+}
 
-		isModified := false
-		for _, header := range zu.Files() {
-			ok, err := filter(header.Name)
-			if err != nil {
-				return false, err
-			}
-			if !ok {
-				continue
-			}
-
-			isModified = true
-			if err := zu.Remove(header.Name); err != nil {
-				return false, err
-			}
+// removeEntries removes the entries of zu selected by filter,
+// and returns the removed names.
+func removeEntries(zu *zip.Updater, filter pathFilter) ([]string, error) {
+	removed := make([]string, 0)
+	for _, header := range zu.Files() {
+		ok, err := filter(header)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
 		}
 
-		return isModified, nil
-	})
+		if err := zu.Remove(header.Name); err != nil {
+			return nil, err
+		}
+		removed = append(removed, header.Name)
+	}
+	return removed, nil
 }
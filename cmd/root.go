@@ -6,8 +6,8 @@ import (
 	"io/ioutil"
 	"os"
 	path "path/filepath"
-	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/bmatcuk/doublestar"
 	"github.com/hidez8891/zip"
@@ -60,14 +60,15 @@ Global Options:
 `
 
 func NewCmd() *cobra.Command {
-	return newRootCmd(os.Stdout, os.Stderr)
+	return newRootCmd(os.Stdin, os.Stdout, os.Stderr)
 }
 
-func newRootCmd(stdout, stderr io.Writer) *cobra.Command {
+func newRootCmd(stdin io.Reader, stdout, stderr io.Writer) *cobra.Command {
 	cmd := &cobra.Command{}
 	cmd.SetOutput(stderr)
 
 	params := &cmdParams{
+		stdin:  stdin,
 		stdout: stdout,
 		stderr: stderr,
 	}
@@ -76,11 +77,48 @@ func newRootCmd(stdout, stderr io.Writer) *cobra.Command {
 	cmd.AddCommand(newRmCmd(params))
 	cmd.AddCommand(newConvertCmd(params))
 	cmd.AddCommand(newRenameCmd(params))
+	cmd.AddCommand(newDiffCmd(params))
+	cmd.AddCommand(newSyncCmd(params))
+	cmd.AddCommand(newMergeCmd(params))
+	cmd.AddCommand(newSplitCmd(params))
+	cmd.AddCommand(newCpCmd(params))
+	cmd.AddCommand(newMvCmd(params))
+	cmd.AddCommand(newCommentCmd(params))
+	cmd.AddCommand(newTouchCmd(params))
+	cmd.AddCommand(newChmodCmd(params))
+	cmd.AddCommand(newNormalizeCmd(params))
+	cmd.AddCommand(newSortCmd(params))
+	cmd.AddCommand(newManifestCmd(params))
+	cmd.AddCommand(newAlignCmd(params))
+	cmd.AddCommand(newScrubMetadataCmd(params))
+	cmd.AddCommand(newCbzCmd(params))
+	cmd.AddCommand(newCleanCmd(params))
 
 	cmd.PersistentFlags().StringVar(&params.pattern, "filter", "", "target filename pattern (support wildcard)")
 	cmd.PersistentFlags().StringVar(&params.regexp, "regexp", "", "target filename pattern (support regexp)")
+	cmd.PersistentFlags().StringArrayVar(&params.includes, "include", nil, "include filename pattern (support wildcard, repeatable)")
+	cmd.PersistentFlags().StringArrayVar(&params.excludes, "exclude", nil, "exclude filename pattern (support wildcard, repeatable)")
+	cmd.PersistentFlags().StringVar(&params.includeFrom, "include-from", "", "read include patterns from file")
+	cmd.PersistentFlags().StringVar(&params.excludeFrom, "exclude-from", "", "read exclude patterns from file")
+	cmd.PersistentFlags().StringVar(&params.expression, "where", "", "target filter expression (e.g. \"name~'*.txt' and size>1MB and not dir\")")
+	cmd.PersistentFlags().BoolVar(&params.ignoreCase, "ignore-case", false, "match filename patterns case-insensitively")
+	cmd.PersistentFlags().BoolVar(&params.fullMatch, "full-match", false, "regexp patterns must match the whole filename")
+	cmd.PersistentFlags().BoolVar(&params.basename, "basename", false, "match --filter and --regexp against the base name only")
+	cmd.PersistentFlags().BoolVarP(&params.recursive, "recursive", "r", false, "search archives in directories recursively")
+	cmd.PersistentFlags().StringSliceVar(&params.archiveExts, "archive-ext", archiveExtensions, "archive file extensions searched by --recursive")
+	cmd.PersistentFlags().BoolVar(&params.detectMagic, "detect-magic", false, "search archives by file signature instead of extension")
+	cmd.PersistentFlags().StringVar(&params.filesFrom, "files-from", "", "read archive paths from file (\"-\" reads from stdin)")
+	cmd.PersistentFlags().BoolVarP(&params.nullSep, "null", "0", false, "archive paths of --files-from are separated by NUL")
+	cmd.PersistentFlags().BoolVar(&params.failOnEmpty, "fail-on-empty-glob", false, "treat a wildcard path matching no files as an error")
+	cmd.PersistentFlags().BoolVar(&params.nested, "nested", false, "process archives nested in archives recursively")
+	cmd.PersistentFlags().StringVar(&params.profile, "profile", profileAuto, "archive format rules kept after edits (auto, none, epub, jar, ooxml, cbz)")
+	cmd.PersistentFlags().BoolVar(&params.epub, "epub", false, "shorthand for --profile epub")
+	cmd.PersistentFlags().StringVar(&params.signature, "jar-signature", signatureWarn, "handling of JAR signatures invalidated by edits (warn, strip)")
+	cmd.PersistentFlags().IntVar(&params.align, "align", 0, "align data of stored files to N bytes (e.g. 4 for Android)")
+	cmd.PersistentFlags().StringArrayVar(&params.alignRules, "align-pattern", nil, "alignment of stored files matching pattern PATTERN=N (e.g. '**/*.so=4096', repeatable)")
 	cmd.PersistentFlags().BoolVar(&params.isOverwrite, "overwrite", false, "overwrite source file")
-	cmd.PersistentFlags().StringVar(&params.outFilename, "out", "", "output file name")
+	cmd.PersistentFlags().StringVar(&params.outFilename, "out", "", "output file name (\"-\" writes to stdout, support {dir}, {name}, {stem} and {ext} templates)")
+	cmd.PersistentFlags().StringVar(&params.outDir, "out-dir", "", "output directory mirroring the input tree")
 
 	cmd.SetUsageTemplate(usageTemplate)
 	cmd.SetHelpTemplate(usageTemplate)
@@ -88,15 +126,75 @@ func newRootCmd(stdout, stderr io.Writer) *cobra.Command {
 	return cmd
 }
 
-func expandFilePath(paths []string) ([]string, error) {
+func (o *cmdParams) expandFilePath(args []string) ([]string, error) {
+	listed, err := o.readFilesFrom()
+	if err != nil {
+		return nil, err
+	}
+	if o.filesFrom == stdioPath {
+		for _, p := range args {
+			if parseArchivePath(p).file == stdioPath {
+				return nil, fmt.Errorf("standard input cannot be used for both file list and archive")
+			}
+		}
+	}
+
+	paths, err := o.expandGlob(append(args, listed...))
+	if err != nil {
+		return nil, err
+	}
+
+	newpaths := make([]string, 0, len(paths))
+	for _, p := range paths {
+		file := parseArchivePath(p).file
+		if file == stdioPath {
+			newpaths = append(newpaths, p)
+			continue
+		}
+
+		st, err := os.Stat(file)
+		if err != nil || !st.IsDir() {
+			newpaths = append(newpaths, p)
+			continue
+		}
+		if !o.recursive {
+			return nil, fmt.Errorf("%s is a directory (use --recursive)", file)
+		}
+		if file != p {
+			return nil, fmt.Errorf("%s: nested path cannot be used with a directory", p)
+		}
+
+		files, err := o.walkArchives(file)
+		if err != nil {
+			return nil, err
+		}
+		newpaths = append(newpaths, files...)
+	}
+	return o.filterArchivePaths(newpaths), nil
+}
+
+func (o *cmdParams) expandGlob(paths []string) ([]string, error) {
 	newpaths := make([]string, 0)
 	for _, path := range paths {
 		if strings.Contains(path, "*") {
+			suffix := ""
+			if i := strings.Index(path, nestedSeparator); i >= 0 {
+				path, suffix = path[:i], path[i:]
+			}
+
 			ps, err := doublestar.Glob(path)
 			if err != nil {
 				return nil, err
 			}
-			newpaths = append(newpaths, ps...)
+			if len(ps) == 0 {
+				if o.failOnEmpty {
+					return nil, fmt.Errorf("%s: no files matched", path)
+				}
+				fmt.Fprintf(o.stderr, "warning: %s: no files matched\n", path)
+			}
+			for _, p := range ps {
+				newpaths = append(newpaths, p+suffix)
+			}
 		} else {
 			newpaths = append(newpaths, path)
 		}
@@ -107,59 +205,98 @@ func expandFilePath(paths []string) ([]string, error) {
 type cmdParams struct {
 	pattern     string
 	regexp      string
+	includes    []string
+	excludes    []string
+	includeFrom string
+	excludeFrom string
+	expression  string
+	ignoreCase  bool
+	fullMatch   bool
+	basename    bool
+	nested      bool
+	profile     string
+	epub        bool
+	signature   string
+	align       int
+	alignRules  []string
+	recursive   bool
+	archiveExts []string
+	detectMagic bool
+	filesFrom   string
+	nullSep     bool
+	failOnEmpty bool
 	isOverwrite bool
 	outFilename string
+	outDir      string
+	outputs     map[string]string
+	stdin       io.Reader
 	stdout      io.Writer
 	stderr      io.Writer
+
+	filterOnce sync.Once
+	filter     pathFilter
+	filterErr  error
+
+	alignOnce  sync.Once
+	alignments []alignRule
+	alignErr   error
 }
 
-func (o *cmdParams) generatePathFilter() (func(string) (bool, error), error) {
-	filter := func(_ string) (bool, error) {
-		return true, nil
+func (o *cmdParams) validateOutputFlag(paths []string) (bool, error) {
+	if err := o.validateProfileFlag(); err != nil {
+		return false, err
 	}
-
-	if len(o.regexp) != 0 {
-		reg, err := regexp.Compile(o.regexp)
+	if len(o.outDir) != 0 && (o.isOverwrite || len(o.outFilename) != 0) {
+		return false, fmt.Errorf("output directory cannot be used with overwrite mode or output file name")
+	}
+	if len(o.outDir) != 0 || (!o.isOverwrite && isOutputTemplate(o.outFilename)) {
+		outputs, err := o.resolveOutputs(paths)
 		if err != nil {
-			return nil, err
-		}
-		filter = func(s string) (bool, error) {
-			return reg.Match([]byte(s)), nil
-		}
-	} else if len(o.pattern) != 0 {
-		filter = func(s string) (bool, error) {
-			return doublestar.Match(o.pattern, s)
+			return false, err
 		}
+		o.outputs = outputs
+		return true, nil
 	}
-	return filter, nil
-}
 
-func (o *cmdParams) validateOutputFlag(paths []string) (bool, error) {
 	if !o.isOverwrite && len(o.outFilename) == 0 {
 		return false, fmt.Errorf("output file name is required")
 	}
 	if !o.isOverwrite && len(paths) > 1 {
-		return false, fmt.Errorf("for multiple files, only overwrite mode is supported")
+		return false, fmt.Errorf("for multiple files, only overwrite mode or output directory is supported")
+	}
+	if err := validateStdinPaths(paths); err != nil {
+		return false, err
+	}
+	for _, filepath := range paths {
+		if o.isOverwrite && parseArchivePath(filepath).file == stdioPath {
+			return false, fmt.Errorf("standard input cannot be overwritten")
+		}
 	}
 	return true, nil
 }
 
-type baseCmd struct {
-	*cmdParams
+func (o *cmdParams) isStdoutOutput() bool {
+	return !o.isOverwrite && o.outFilename == stdioPath
 }
 
-func (o *baseCmd) openZipUpdater(filepath string) (*os.File, *zip.Updater, error) {
-	st, err := os.Stat(filepath)
-	if err != nil {
-		return nil, nil, err
+func (o *cmdParams) progressOutput() io.Writer {
+	if o.isStdoutOutput() {
+		return o.stderr
 	}
+	return o.stdout
+}
+
+type baseCmd struct {
+	*cmdParams
+}
 
-	file, err := os.Open(filepath)
+func (o *baseCmd) openZipUpdater(filepath string) (*inputFile, *zip.Updater, error) {
+	file, err := o.openInput(filepath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	zu, err := zip.NewUpdater(file, st.Size())
+	zu, err := zip.NewUpdater(file, file.size)
 	if err != nil {
 		file.Close()
 		return nil, nil, err
@@ -173,7 +310,7 @@ func (o *baseCmd) openOutput(filepath string) (*os.File, error) {
 		filename := path.Base(filepath)
 		return ioutil.TempFile("", filename)
 	}
-	return os.OpenFile(o.outFilename, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0666)
+	return createOutputFile(o.outputPath(filepath))
 }
 
 func (o *baseCmd) overWriteFile(filepath string, data *os.File) error {
@@ -191,7 +328,24 @@ func (o *baseCmd) overWriteFile(filepath string, data *os.File) error {
 	return nil
 }
 
-func (o *baseCmd) editZipFile(filepath string, editor func(*zip.Updater) (bool, error)) error {
+// zipEditor edits the entries of zu selected by filter,
+// and reports whether zu was modified.
+type zipEditor func(zu *zip.Updater, filter pathFilter) (bool, error)
+
+// nestedZipEditor is a zipEditor which also receives the address of zu
+// under the input archive, like "inner.zip!/" with the nested option.
+type nestedZipEditor func(zu *zip.Updater, filter pathFilter, nested string) (bool, error)
+
+func (o *baseCmd) editZipFile(address string, editor zipEditor) error {
+	return o.editNestedZipFile(address, func(zu *zip.Updater, filter pathFilter, nested string) (bool, error) {
+		return editor(zu, filter)
+	})
+}
+
+func (o *baseCmd) editNestedZipFile(address string, editor nestedZipEditor) error {
+	apath := parseArchivePath(address)
+	filepath := apath.file
+
 	file, zu, err := o.openZipUpdater(filepath)
 	if err != nil {
 		return err
@@ -199,9 +353,40 @@ func (o *baseCmd) editZipFile(filepath string, editor func(*zip.Updater) (bool,
 	defer close(file)
 	defer close(zu)
 
-	if ok, err := editor(zu); !ok {
+	ok, err := o.editNestedZip(zu, apath.nested, apath.scopeFilter(), editor)
+	if err != nil {
 		return err
 	}
+	if !ok {
+		if !o.isStdoutOutput() {
+			return nil
+		}
+		// the pipeline still needs the archive which is not modified
+		return o.writeArchive(filepath, func(w io.Writer) error {
+			_, err := io.Copy(w, io.NewSectionReader(file, 0, file.size))
+			return err
+		})
+	}
+
+	// the profile is already applied by editNestedZip
+	return o.writeArchive(filepath, zu.SaveAs, zu, file)
+}
+
+// writeOutput writes the archive of filepath created by save,
+// keeping the rules of the archive profile.
+// inputs are closed before the source file is overwritten.
+func (o *baseCmd) writeOutput(filepath string, save func(io.Writer) error, inputs ...io.Closer) error {
+	return o.writeArchive(filepath, o.profiledSave(save), inputs...)
+}
+
+func (o *baseCmd) writeArchive(filepath string, save func(io.Writer) error, inputs ...io.Closer) error {
+	if o.isAligned() {
+		save = o.alignedSave(save)
+	}
+
+	if o.isStdoutOutput() {
+		return save(o.stdout)
+	}
 
 	outfile, err := o.openOutput(filepath)
 	if err != nil {
@@ -209,13 +394,12 @@ func (o *baseCmd) editZipFile(filepath string, editor func(*zip.Updater) (bool,
 	}
 	defer close(outfile)
 
-	if err := zu.SaveAs(outfile); err != nil {
+	if err := save(outfile); err != nil {
 		return err
 	}
-	zu.Close()
-	file.Close()
-	zu = nil
-	file = nil
+	for _, input := range inputs {
+		input.Close()
+	}
 
 	if o.isOverwrite {
 		if err := o.overWriteFile(filepath, outfile); err != nil {
@@ -231,7 +415,7 @@ func (o *baseCmd) editZipFile(filepath string, editor func(*zip.Updater) (bool,
 }
 
 type toolParallelCmd struct {
-	writer       io.Writer
+	params       *cmdParams
 	jobs         uint
 	showProgress bool
 }
@@ -250,7 +434,7 @@ func (o *toolParallelCmd) flagValidate() error {
 
 func (o *toolParallelCmd) execute(paths []string, executer func(string) error) []error {
 	progress := pb.New(len(paths))
-	progress.Output = o.writer
+	progress.Output = o.params.progressOutput()
 	if !o.showProgress {
 		progress.Output = ioutil.Discard
 	}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ooxmlCorePropsName = "docProps/core.xml"
+	ooxmlAppPropsName  = "docProps/app.xml"
+)
+
+// scrubbedProperties are the elements of the document properties
+// which identify the people and the organization.
+var scrubbedProperties = map[string][]string{
+	ooxmlCorePropsName: {"creator", "lastModifiedBy"},
+	ooxmlAppPropsName:  {"Company", "Manager"},
+}
+
+func newScrubMetadataCmd(params *cmdParams) *cobra.Command {
+	scrubcmd := &scrubMetadata{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "scrub-metadata [filepath...]",
+		Short: "Clear author and company of Office documents",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			scrubcmd.run(cmd, args)
+		},
+	}
+
+	scrubcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type scrubMetadata struct {
+	*baseCmd
+	pexe *toolParallelCmd
+}
+
+func (o *scrubMetadata) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *scrubMetadata) execute(filepath string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		if !isOOXML(zu) {
+			return false, nil
+		}
+
+		isModified := false
+		for name, elements := range scrubbedProperties {
+			if findHeader(zu, name) == nil {
+				continue
+			}
+
+			ok, err := scrubProperties(zu, name, elements)
+			if err != nil {
+				return false, err
+			}
+			isModified = isModified || ok
+		}
+		return isModified, nil
+	})
+}
+
+// scrubProperties empties the elements of the part. The elements are
+// matched by the local name, as the prefixes depend on the producer.
+func scrubProperties(zu *zip.Updater, name string, elements []string) (bool, error) {
+	data, err := readUpdaterEntry(zu, name)
+	if err != nil {
+		return false, err
+	}
+
+	result := data
+	for _, elem := range elements {
+		re := regexp.MustCompile(`(<(?:[\w.-]+:)?` + elem + `(?:\s[^>]*)?>)[^<]*(</(?:[\w.-]+:)?` + elem + `\s*>)`)
+		result = re.ReplaceAll(result, []byte("$1$2"))
+	}
+	if string(result) == string(data) {
+		return false, nil
+	}
+
+	return true, writeUpdaterEntry(zu, name, result)
+}
@@ -0,0 +1,268 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newSortCmd(params *cmdParams) *cobra.Command {
+	sortcmd := &reorder{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "sort [filepath...]",
+		Short: "Reorder files",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			sortcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&sortcmd.by, "by", orderByName, "sort key (name, natural, size, mtime, list)")
+	cmd.Flags().StringVar(&sortcmd.listFile, "list", "", "file listing entry names in order (implies --by list)")
+	cmd.Flags().StringArrayVar(&sortcmd.first, "first", nil, "put files matching pattern first (support wildcard, repeatable)")
+	cmd.Flags().BoolVar(&sortcmd.reverse, "reverse", false, "sort in reverse order")
+	sortcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+const (
+	orderByName    = "name"
+	orderByNatural = "natural"
+	orderBySize    = "size"
+	orderByMtime   = "mtime"
+	orderByList    = "list"
+)
+
+type reorder struct {
+	*baseCmd
+	pexe     *toolParallelCmd
+	by       string
+	listFile string
+	first    []string
+	reverse  bool
+	list     map[string]int
+	matchers []nameMatcher
+}
+
+func (o *reorder) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.validate(cmd); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *reorder) validate(cmd *cobra.Command) error {
+	if len(o.listFile) != 0 {
+		if cmd.Flags().Changed("by") && o.by != orderByList {
+			return fmt.Errorf("--list cannot be used with --by %s", o.by)
+		}
+		o.by = orderByList
+	}
+
+	switch o.by {
+	case orderByName, orderByNatural, orderBySize, orderByMtime:
+	case orderByList:
+		if len(o.listFile) == 0 {
+			return fmt.Errorf("--by list requires --list")
+		}
+		names, err := o.loadPatterns(nil, o.listFile)
+		if err != nil {
+			return err
+		}
+		o.list = make(map[string]int, len(names))
+		for i, name := range names {
+			if _, ok := o.list[name]; !ok {
+				o.list[name] = i
+			}
+		}
+	default:
+		return fmt.Errorf("unknown sort key: %s", o.by)
+	}
+
+	for _, pattern := range o.first {
+		m, err := o.globMatcher(pattern)
+		if err != nil {
+			return err
+		}
+		o.matchers = append(o.matchers, m)
+	}
+	return nil
+}
+
+func (o *reorder) execute(filepath string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		headers := zu.Files()
+		order := make([]string, len(headers))
+		for i, header := range headers {
+			order[i] = header.Name
+		}
+
+		if err := o.sortHeaders(headers); err != nil {
+			return false, err
+		}
+
+		isModified := false
+		for i, header := range headers {
+			if order[i] != header.Name {
+				isModified = true
+			}
+		}
+		if !isModified {
+			return false, nil
+		}
+
+		return true, zu.Sort(func([]string) []string {
+			names := make([]string, len(headers))
+			for i, header := range headers {
+				names[i] = header.Name
+			}
+			return names
+		})
+	})
+}
+
+// sortHeaders sorts headers stably by the key, and then moves
+// the headers matching --first patterns to the front.
+func (o *reorder) sortHeaders(headers []*zip.FileHeader) error {
+	less := o.less()
+	sort.SliceStable(headers, func(i, j int) bool {
+		if o.reverse {
+			return less(headers[j], headers[i])
+		}
+		return less(headers[i], headers[j])
+	})
+
+	if len(o.matchers) == 0 {
+		return nil
+	}
+
+	rank := make(map[*zip.FileHeader]int, len(headers))
+	for _, header := range headers {
+		rank[header] = len(o.matchers)
+		for i, m := range o.matchers {
+			ok, err := m(header.Name)
+			if err != nil {
+				return err
+			}
+			if ok {
+				rank[header] = i
+				break
+			}
+		}
+	}
+	sort.SliceStable(headers, func(i, j int) bool {
+		return rank[headers[i]] < rank[headers[j]]
+	})
+	return nil
+}
+
+func (o *reorder) less() func(h1, h2 *zip.FileHeader) bool {
+	switch o.by {
+	case orderByNatural:
+		return func(h1, h2 *zip.FileHeader) bool {
+			return naturalLess(h1.Name, h2.Name)
+		}
+	case orderBySize:
+		return func(h1, h2 *zip.FileHeader) bool {
+			return h1.UncompressedSize64 < h2.UncompressedSize64
+		}
+	case orderByMtime:
+		return func(h1, h2 *zip.FileHeader) bool {
+			return headerModTime(h1).Before(headerModTime(h2))
+		}
+	case orderByList:
+		// unlisted files follow the listed files in the current order
+		return func(h1, h2 *zip.FileHeader) bool {
+			i1, ok1 := o.list[h1.Name]
+			i2, ok2 := o.list[h2.Name]
+			if !ok1 || !ok2 {
+				return ok1 && !ok2
+			}
+			return i1 < i2
+		}
+	default:
+		return func(h1, h2 *zip.FileHeader) bool {
+			return h1.Name < h2.Name
+		}
+	}
+}
+
+// naturalLess compares s1 and s2 treating runs of digits as numbers,
+// so that "file2" comes before "file10".
+func naturalLess(s1, s2 string) bool {
+	i, j := 0, 0
+	for i < len(s1) && j < len(s2) {
+		c1, c2 := s1[i], s2[j]
+		if !isDigit(c1) || !isDigit(c2) {
+			if c1 != c2 {
+				return c1 < c2
+			}
+			i++
+			j++
+			continue
+		}
+
+		// compare the numbers ignoring leading zeros
+		start1, start2 := i, j
+		for i < len(s1) && isDigit(s1[i]) {
+			i++
+		}
+		for j < len(s2) && isDigit(s2[j]) {
+			j++
+		}
+		n1 := trimLeadingZeros(s1[start1:i])
+		n2 := trimLeadingZeros(s2[start2:j])
+		if len(n1) != len(n2) {
+			return len(n1) < len(n2)
+		}
+		if n1 != n2 {
+			return n1 < n2
+		}
+		// fewer leading zeros first
+		if i-start1 != j-start2 {
+			return i-start1 < j-start2
+		}
+	}
+	return len(s1)-i < len(s2)-j
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func trimLeadingZeros(s string) string {
+	for len(s) > 1 && s[0] == '0' {
+		s = s[1:]
+	}
+	return s
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNaturalLess(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   bool
+	}{
+		{"file2.txt", "file10.txt", true},
+		{"file10.txt", "file2.txt", false},
+		{"a/b", "a/c", true},
+		{"file02", "file2", false},
+		{"file2", "file02", true},
+		{"file", "file1", true},
+		{"file1", "file1", false},
+		{"page9/1.jpg", "page10/0.jpg", true},
+	}
+
+	for _, tt := range tests {
+		if got := naturalLess(tt.s1, tt.s2); got != tt.want {
+			t.Fatalf("naturalLess(%q, %q)=%v, want %v", tt.s1, tt.s2, got, tt.want)
+		}
+	}
+}
+
+func TestSortExecuteOverwrite(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	listFile := filepath.Join(tmpdir, "order.txt")
+	if err := ioutil.WriteFile(listFile, []byte("dir/text2.txt\n\ntext1.txt\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+	}{
+		{
+			name: "reverse",
+			args: []string{"sort", "--overwrite", "--reverse", "--show-progress=false"},
+			contents: []string{
+				"text1.txt",
+				"dir/text2.txt",
+				"dir/text1.txt",
+				"dir/",
+			},
+		},
+		{
+			name: "size_reverse",
+			args: []string{"sort", "--overwrite", "--by", "size", "--reverse", "--show-progress=false"},
+			contents: []string{
+				"text1.txt",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"dir/",
+			},
+		},
+		{
+			name: "mtime",
+			args: []string{"sort", "--overwrite", "--by", "mtime", "--show-progress=false"},
+			contents: []string{
+				"text1.txt",
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+		{
+			name: "first",
+			args: []string{"sort", "--overwrite", "--first", "text1.txt", "--show-progress=false"},
+			contents: []string{
+				"text1.txt",
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+			},
+		},
+		{
+			name: "list",
+			args: []string{"sort", "--overwrite", "--list", listFile, "--show-progress=false"},
+			contents: []string{
+				"dir/text2.txt",
+				"text1.txt",
+				"dir/",
+				"dir/text1.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			helperExecuteCommand(t, append(tt.args, tmpname))
+			helperRmCheckFileContents(t, tmpname, tt.contents)
+			helperConvertCheckFileContents(t, tmpname, map[string]string{
+				"dir/text1.txt": "test 1",
+				"text1.txt":     "hello world",
+			})
+		})
+	}
+}
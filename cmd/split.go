@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultSplitOutput   = "{dir}/{stem}-{n}{ext}"
+	defaultSplitManifest = "{dir}/{stem}.manifest"
+)
+
+func newSplitCmd(params *cmdParams) *cobra.Command {
+	splitcmd := &split{
+		baseCmd: &baseCmd{params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "split [filepath]",
+		Short: "Split archive into multiple archives",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			splitcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&splitcmd.maxSize, "max-size", "", "maximum size of each output archive (e.g. 100MB)")
+	cmd.Flags().IntVar(&splitcmd.maxEntries, "max-entries", 0, "maximum number of entries of each output archive")
+	cmd.Flags().BoolVar(&splitcmd.byDir, "by-dir", false, "split by top-level directory")
+	cmd.Flags().StringVar(&splitcmd.manifest, "manifest", defaultSplitManifest, "manifest file name (\"-\" writes to stdout, empty disables)")
+	return cmd
+}
+
+type split struct {
+	*baseCmd
+	maxSize    string
+	maxEntries int
+	byDir      bool
+	manifest   string
+	sizeLimit  uint64
+}
+
+// splitPart is an output archive of split.
+type splitPart struct {
+	name  string
+	size  uint64
+	files []*zip.File
+	names map[string]bool
+}
+
+func newSplitPart() *splitPart {
+	return &splitPart{size: endRecordLen, names: make(map[string]bool)}
+}
+
+// add appends zf after the directory entries of dirs which zf needs.
+func (p *splitPart) add(zf *zip.File, dirs map[string]*zip.File) {
+	for _, dir := range p.missingDirs(zf, dirs) {
+		p.append(dir)
+	}
+	p.append(zf)
+}
+
+func (p *splitPart) append(zf *zip.File) {
+	if p.names[zf.Name] {
+		return
+	}
+	p.names[zf.Name] = true
+	p.files = append(p.files, zf)
+	p.size += entrySize(zf)
+}
+
+// missingDirs returns the parent directory entries of zf which p does not have.
+func (p *splitPart) missingDirs(zf *zip.File, dirs map[string]*zip.File) []*zip.File {
+	missing := make([]*zip.File, 0)
+	elems := strings.Split(strings.TrimSuffix(zf.Name, "/"), "/")
+	for i := 1; i < len(elems); i++ {
+		dir, ok := dirs[strings.Join(elems[:i], "/")+"/"]
+		if ok && !p.names[dir.Name] {
+			missing = append(missing, dir)
+		}
+	}
+	return missing
+}
+
+// endRecordLen is the size of the end of central directory record.
+const endRecordLen = 22
+
+func (o *split) run(cmd *cobra.Command, args []string) {
+	if err := o.validate(); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.execute(args[0]); err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+func (o *split) validate() error {
+	if err := o.validateProfileFlag(); err != nil {
+		return err
+	}
+	modes := 0
+	if len(o.maxSize) != 0 {
+		size, err := parseSize(o.maxSize)
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return fmt.Errorf("maximum size must be positive")
+		}
+		o.sizeLimit = size
+		modes++
+	}
+	if o.maxEntries < 0 {
+		return fmt.Errorf("maximum number of entries must be positive")
+	}
+	if o.maxEntries > 0 {
+		modes++
+	}
+	if o.byDir {
+		modes++
+	}
+	if modes != 1 {
+		return fmt.Errorf("one of --max-size, --max-entries or --by-dir is required")
+	}
+
+	if o.isOverwrite || len(o.outDir) != 0 || o.outFilename == stdioPath {
+		return fmt.Errorf("split supports only an output file name template")
+	}
+	if len(o.outFilename) == 0 {
+		o.outFilename = defaultSplitOutput
+	}
+	if !strings.Contains(o.outFilename, "{n}") {
+		return fmt.Errorf("output file name must contain {n}")
+	}
+	return nil
+}
+
+func (o *split) execute(address string) error {
+	apath := parseArchivePath(address)
+	zr, closer, err := o.openZipReader(apath)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	filter, err := o.generatePathFilter()
+	if err != nil {
+		return err
+	}
+	filter = andFilter(apath.scopeFilter(), filter)
+
+	files := make([]*zip.File, 0, len(zr.File))
+	dirs := make(map[string]*zip.File)
+	for _, zf := range zr.File {
+		if strings.HasSuffix(zf.Name, "/") {
+			dirs[zf.Name] = zf
+		}
+		ok, err := filter(&zf.FileHeader)
+		if err != nil {
+			return err
+		}
+		if ok {
+			files = append(files, zf)
+		}
+	}
+
+	parts := o.partition(files, dirs)
+	for i, part := range parts {
+		name := expandOutputTemplate(o.outFilename, apath.file)
+		part.name = strings.Replace(name, "{n}", strconv.Itoa(i+1), -1)
+
+		if o.sizeLimit != 0 && part.size > o.sizeLimit {
+			fmt.Fprintf(o.stderr, "warning: %s: %s exceeds the maximum size\n", part.name, part.files[len(part.files)-1].Name)
+		}
+		if o.maxEntries > 0 && len(part.files) > o.maxEntries {
+			fmt.Fprintf(o.stderr, "warning: %s: %s has more parent directories than the maximum entries\n", part.name, part.files[len(part.files)-1].Name)
+		}
+	}
+
+	for _, part := range parts {
+		if err := o.writePart(part); err != nil {
+			return err
+		}
+	}
+	return o.writeManifest(apath.file, parts)
+}
+
+// partition groups files into parts keeping the order of the entries.
+// Each part has the directory entries of dirs which its files need.
+// An entry larger than the maximum size makes a part by itself.
+func (o *split) partition(files []*zip.File, dirs map[string]*zip.File) []*splitPart {
+	parts := make([]*splitPart, 0)
+
+	if o.byDir {
+		index := make(map[string]*splitPart)
+		for _, zf := range files {
+			dir := topLevelDir(zf.Name)
+			part, ok := index[dir]
+			if !ok {
+				part = newSplitPart()
+				index[dir] = part
+				parts = append(parts, part)
+			}
+			part.add(zf, dirs)
+		}
+		return parts
+	}
+
+	var part *splitPart
+	for _, zf := range files {
+		if part != nil && part.names[zf.Name] {
+			continue
+		}
+
+		if part == nil || !o.fits(part, zf, dirs) {
+			part = newSplitPart()
+			parts = append(parts, part)
+		}
+		part.add(zf, dirs)
+	}
+	return parts
+}
+
+// fits reports whether part can have zf with the parent directories
+// which part does not have yet within the limits.
+func (o *split) fits(part *splitPart, zf *zip.File, dirs map[string]*zip.File) bool {
+	missing := part.missingDirs(zf, dirs)
+	if o.maxEntries > 0 && len(part.files)+len(missing)+1 > o.maxEntries {
+		return false
+	}
+
+	size := entrySize(zf)
+	for _, dir := range missing {
+		size += entrySize(dir)
+	}
+	return o.sizeLimit == 0 || part.size+size <= o.sizeLimit
+}
+
+// entrySize estimates the bytes which zf occupies in an archive.
+func entrySize(zf *zip.File) uint64 {
+	const (
+		localHeaderLen   = 30
+		centralHeaderLen = 46
+	)
+
+	size := uint64(localHeaderLen + centralHeaderLen)
+	size += uint64(2*len(zf.Name) + 2*len(zf.Extra) + len(zf.Comment))
+	size += zf.CompressedSize64
+	if zf.Flags&zip.FlagDataDescriptor != 0 {
+		size += 24
+	}
+	return size
+}
+
+// topLevelDir returns the first element of name, or "" for a top-level file.
+func topLevelDir(name string) string {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+func (o *split) writePart(part *splitPart) error {
+	file, err := createOutputFile(part.name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	save := func(w io.Writer) error {
+		zw := zip.NewWriter(w)
+		for _, zf := range part.files {
+			if err := zw.CopyFile(zf); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}
+	save = o.profiledSave(save)
+	if o.isAligned() {
+		save = o.alignedSave(save)
+	}
+	return save(file)
+}
+
+// writeManifest writes the part and the name of each entry separated by a tab.
+func (o *split) writeManifest(filepath string, parts []*splitPart) error {
+	if len(o.manifest) == 0 {
+		return nil
+	}
+
+	var w io.Writer = o.stdout
+	if o.manifest != stdioPath {
+		file, err := createOutputFile(expandOutputTemplate(o.manifest, filepath))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	for _, part := range parts {
+		for _, zf := range part.files {
+			if _, err := fmt.Fprintf(w, "%s\t%s\n", part.name, zf.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
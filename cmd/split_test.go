@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestSplitExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		parts [][]string
+	}{
+		{
+			name: "max_entries",
+			args: []string{
+				"split",
+				"--max-entries",
+				"3",
+			},
+			parts: [][]string{
+				{"dir/", "dir/text1.txt", "dir/text2.txt"},
+				{"text1.txt"},
+			},
+		},
+		{
+			name: "max_size",
+			args: []string{
+				"split",
+				"--max-size",
+				"400B",
+			},
+			parts: [][]string{
+				{"dir/", "dir/text1.txt"},
+				{"dir/", "dir/text2.txt"},
+				{"text1.txt"},
+			},
+		},
+		{
+			name: "by_dir",
+			args: []string{
+				"split",
+				"--by-dir",
+			},
+			parts: [][]string{
+				{"dir/", "dir/text1.txt", "dir/text2.txt"},
+				{"text1.txt"},
+			},
+		},
+		{
+			name: "single_entry_over_size",
+			args: []string{
+				"split",
+				"--max-size",
+				"1",
+				"--filter",
+				"**/*.txt",
+			},
+			parts: [][]string{
+				{"dir/", "dir/text1.txt"},
+				{"dir/", "dir/text2.txt"},
+				{"text1.txt"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpdir, err := ioutil.TempDir("", "ziped")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(tmpdir)
+
+			src := helperCopyFileTo(t, "../testcase/test.zip", filepath.Join(tmpdir, "test.zip"))
+			cmd := newRootCmd(new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer))
+			cmd.SetArgs(append(tt.args, src))
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			manifest := make([]string, 0)
+			for i, contents := range tt.parts {
+				part := filepath.Join(tmpdir, "test-"+strconv.Itoa(i+1)+".zip")
+				helperRmCheckFileContents(t, part, contents)
+				for _, name := range contents {
+					manifest = append(manifest, part+"\t"+name)
+				}
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join(tmpdir, "test.manifest"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := strings.Join(manifest, "\n") + "\n"; string(data) != want {
+				t.Fatalf("manifest=%q, want %q", string(data), want)
+			}
+		})
+	}
+}
+
+func TestSplitMaxSize(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := helperCopyFileTo(t, "../testcase/test2.zip", filepath.Join(tmpdir, "test.zip"))
+	helperExecuteCommand(t, []string{
+		"split",
+		"--max-size",
+		"400B",
+		"--manifest",
+		"",
+		"--out",
+		filepath.Join(tmpdir, "part{n}.zip"),
+		src,
+	})
+
+	files, err := filepath.Glob(filepath.Join(tmpdir, "part*.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("parts=%v, want multiple parts", files)
+	}
+	for _, file := range files {
+		st, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if st.Size() > 400 {
+			t.Fatalf("%s: size=%d, want <= 400", file, st.Size())
+		}
+	}
+}
+
+func TestSplitMaxEntriesNested(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	src := filepath.Join(tmpdir, "test.zip")
+	helperCreateArchive(t, src, []testEntry{
+		{name: "x.txt", body: "x"},
+		{name: "a/"},
+		{name: "a/b/"},
+		{name: "a/b/c.txt", body: "c"},
+		{name: "a/e.txt", body: "e"},
+	})
+	helperExecuteCommand(t, []string{
+		"split",
+		"--max-entries",
+		"3",
+		"--filter",
+		"**/*.txt",
+		"--manifest",
+		"",
+		"--out",
+		filepath.Join(tmpdir, "part{n}.zip"),
+		src,
+	})
+
+	files, err := filepath.Glob(filepath.Join(tmpdir, "part*.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n := len(zr.File)
+		zr.Close()
+		if n > 3 {
+			t.Fatalf("%s: entries=%d, want <= 3", file, n)
+		}
+	}
+
+	parts := [][]string{
+		{"x.txt"},
+		{"a/", "a/b/", "a/b/c.txt"},
+		{"a/", "a/e.txt"},
+	}
+	if len(files) != len(parts) {
+		t.Fatalf("parts=%v, want %d parts", files, len(parts))
+	}
+	for i, contents := range parts {
+		helperRmCheckFileContents(t, filepath.Join(tmpdir, "part"+strconv.Itoa(i+1)+".zip"), contents)
+	}
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// stdioPath is the file path that means standard input or output.
+const stdioPath = "-"
+
+// inputFile is an opened source archive.
+// Standard input is buffered into a temporary file to allow random access.
+type inputFile struct {
+	*os.File
+	size int64
+	temp bool
+}
+
+func (f *inputFile) Close() error {
+	err := f.File.Close()
+	if f.temp {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func (o *cmdParams) openInput(filepath string) (*inputFile, error) {
+	if filepath == stdioPath {
+		return o.bufferStdin()
+	}
+
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	st, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &inputFile{File: file, size: st.Size()}, nil
+}
+
+func (o *cmdParams) bufferStdin() (*inputFile, error) {
+	tmp, err := ioutil.TempFile("", "ziped-stdin")
+	if err != nil {
+		return nil, err
+	}
+
+	file := &inputFile{File: tmp, temp: true}
+	size, err := io.Copy(tmp, o.stdin)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	file.size = size
+	return file, nil
+}
+
+func validateStdinPaths(paths []string) error {
+	count := 0
+	for _, filepath := range paths {
+		if parseArchivePath(filepath).file == stdioPath {
+			count++
+		}
+	}
+	if count > 1 {
+		return fmt.Errorf("standard input can be specified only once")
+	}
+	return nil
+}
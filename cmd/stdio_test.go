@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestStdinLsRender(t *testing.T) {
+	data, err := ioutil.ReadFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(bytes.NewReader(data), stdout, stderr)
+	cmd.SetArgs([]string{"ls", "--filter", "dir/*", "-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	want := strings.Join([]string{
+		"dir/text1.txt",
+		"dir/text2.txt",
+	}, "\n") + "\n"
+	if out := stdout.String(); out != want {
+		t.Fatalf("output=%q, want %q", out, want)
+	}
+}
+
+func TestStdinRmToStdout(t *testing.T) {
+	data, err := ioutil.ReadFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(bytes.NewReader(data), stdout, stderr)
+	cmd.SetArgs([]string{"rm", "--filter", "dir/*", "--out", "-", "--show-progress=false", "-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(stdout.Bytes()), int64(stdout.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := []string{
+		"dir/",
+		"text1.txt",
+	}
+	if len(zr.File) != len(contents) {
+		t.Fatalf("update filename count=%d, want %d", len(zr.File), len(contents))
+	}
+	for i, zf := range zr.File {
+		if zf.Name != contents[i] {
+			t.Fatalf("update filename=%q, want %q", zf.Name, contents[i])
+		}
+	}
+}
+
+func TestStdinOverwrite(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{"rm", "--overwrite", "--show-progress=false", "-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() == 0 {
+		t.Fatalf("overwriting standard input is accepted")
+	}
+}
+
+func TestStdinUnmodifiedToStdout(t *testing.T) {
+	data, err := ioutil.ReadFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(bytes.NewReader(data), stdout, stderr)
+	cmd.SetArgs([]string{"rm", "--filter", "notfound/*", "--out", "-", "--show-progress=false", "-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+	if !bytes.Equal(stdout.Bytes(), data) {
+		t.Fatalf("output size=%d, want the input of size %d", stdout.Len(), len(data))
+	}
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	path "path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newSyncCmd(params *cmdParams) *cobra.Command {
+	synccmd := &synchronize{
+		baseCmd: &baseCmd{params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "sync [filepath] [directory]",
+		Short: "Update archive from directory",
+		Args:  cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			synccmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().BoolVar(&synccmd.checksum, "checksum", false, "detect changed files by content instead of size and mtime")
+	cmd.Flags().BoolVar(&synccmd.delete, "delete", false, "remove entries not existing in directory")
+	cmd.Flags().BoolVar(&synccmd.dryRun, "dry-run", false, "show changes without updating archive")
+	return cmd
+}
+
+type synchronize struct {
+	*baseCmd
+	checksum bool
+	delete   bool
+	dryRun   bool
+}
+
+type syncAction struct {
+	op   string
+	name string
+	file *entryInfo
+}
+
+const (
+	syncAdd    = "add"
+	syncUpdate = "update"
+	syncDelete = "delete"
+)
+
+func (o *synchronize) run(cmd *cobra.Command, args []string) {
+	filepath, dir := args[0], args[1]
+
+	if st, err := os.Stat(dir); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	} else if !st.IsDir() {
+		fmt.Fprintf(o.stderr, "%s is not a directory\n", dir)
+		return
+	}
+
+	// dry run needs no output, but the given one must be valid
+	if o.dryRun && o.isStdoutOutput() {
+		fmt.Fprintln(o.stderr, "dry run cannot output archive to standard output")
+		return
+	}
+	if !o.dryRun || o.isOverwrite || len(o.outFilename) != 0 || len(o.outDir) != 0 {
+		if ok, err := o.validateOutputFlag([]string{filepath}); !ok {
+			fmt.Fprintln(o.stderr, err.Error())
+			return
+		}
+	}
+
+	if err := o.execute(filepath, dir); err != nil {
+		fmt.Fprintln(o.stderr, err)
+	}
+}
+
+func (o *synchronize) execute(filepath, dir string) error {
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		actions, err := o.plan(zu, filter, dir)
+		if err != nil {
+			return false, err
+		}
+
+		if o.dryRun {
+			for _, action := range actions {
+				fmt.Fprintf(o.stdout, "%s: %s\n", action.op, action.name)
+			}
+			return false, nil
+		}
+
+		for _, action := range actions {
+			if err := o.apply(zu, action); err != nil {
+				return false, err
+			}
+		}
+		return len(actions) != 0, nil
+	})
+}
+
+// plan lists the changes that make zu the same as dir.
+func (o *synchronize) plan(zu *zip.Updater, filter pathFilter, dir string) ([]*syncAction, error) {
+	files, err := o.directoryEntries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make(map[string]*zip.FileHeader)
+	for _, header := range zu.Files() {
+		ok, err := filter(header)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			headers[header.Name] = header
+		}
+	}
+
+	actions := make([]*syncAction, 0)
+	exists := make(map[string]bool)
+	for _, file := range files {
+		exists[file.name] = true
+
+		header, ok := headers[file.name]
+		if !ok {
+			if findHeader(zu, file.name) == nil {
+				actions = append(actions, &syncAction{syncAdd, file.name, file})
+			}
+			continue
+		}
+
+		changed, err := o.isChanged(header, file)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			actions = append(actions, &syncAction{syncUpdate, file.name, file})
+		}
+	}
+
+	if o.delete {
+		for _, header := range zu.Files() {
+			if _, ok := headers[header.Name]; !ok || exists[header.Name] {
+				continue
+			}
+			if strings.HasSuffix(header.Name, "/") {
+				st, err := os.Stat(path.Join(dir, path.FromSlash(header.Name)))
+				if err == nil && st.IsDir() {
+					continue
+				}
+			}
+			actions = append(actions, &syncAction{syncDelete, header.Name, nil})
+		}
+	}
+	return actions, nil
+}
+
+func (o *synchronize) isChanged(header *zip.FileHeader, file *entryInfo) (bool, error) {
+	if header.UncompressedSize64 != file.size {
+		return true, nil
+	}
+
+	if o.checksum {
+		crc, err := fileCRC32(file.open)
+		if err != nil {
+			return false, err
+		}
+		return crc != header.CRC32, nil
+	}
+
+	modified := headerModTime(header)
+	// MS-DOS time has 2 seconds resolution
+	diff := file.modified.Sub(modified)
+	return diff >= 2*time.Second || diff <= -2*time.Second, nil
+}
+
+func (o *synchronize) apply(zu *zip.Updater, action *syncAction) error {
+	if action.op == syncDelete {
+		return zu.Remove(action.name)
+	}
+
+	var w io.WriteCloser
+	var err error
+	if action.op == syncAdd {
+		w, err = zu.Create(action.name)
+	} else {
+		w, err = zu.Update(action.name)
+	}
+	if err != nil {
+		return err
+	}
+
+	r, err := action.file.open()
+	if err != nil {
+		w.Close()
+		return err
+	}
+	defer r.Close()
+
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	header := findHeader(zu, action.name)
+	setModTime(header, action.file.modified)
+	if action.op == syncAdd {
+		header.SetMode(action.file.mode)
+		return createParentDirs(zu, action.name)
+	}
+	return nil
+}
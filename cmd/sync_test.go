@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func helperSyncCreateDirectory(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	tmpdir, err := ioutil.TempDir("", "ziped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		filename := filepath.Join(tmpdir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(body), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tmpdir
+}
+
+func TestSyncExecuteOverwrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		contents []string
+		bodies   map[string]string
+	}{
+		{
+			name: "checksum_and_delete",
+			args: []string{
+				"sync",
+				"--overwrite",
+				"--checksum",
+				"--delete",
+			},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"new.txt",
+				"sub/deep/new.txt",
+				"sub/",
+				"sub/deep/",
+			},
+			bodies: map[string]string{
+				"dir/text1.txt":    "test 1",
+				"dir/text2.txt":    "updated",
+				"new.txt":          "new file",
+				"sub/deep/new.txt": "deep file",
+			},
+		},
+		{
+			name: "mtime_without_delete",
+			args: []string{
+				"sync",
+				"--overwrite",
+			},
+			contents: []string{
+				"dir/",
+				"dir/text1.txt",
+				"dir/text2.txt",
+				"text1.txt",
+				"new.txt",
+				"sub/deep/new.txt",
+				"sub/",
+				"sub/deep/",
+			},
+			bodies: map[string]string{
+				"dir/text1.txt": "test 1",
+				"dir/text2.txt": "updated",
+				"text1.txt":     "hello world",
+				"new.txt":       "new file",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := helperSyncCreateDirectory(t, map[string]string{
+				"dir/text1.txt":    "test 1",
+				"dir/text2.txt":    "updated",
+				"new.txt":          "new file",
+				"sub/deep/new.txt": "deep file",
+			})
+			defer os.RemoveAll(dir)
+
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+
+			helperExecuteCommand(t, append(tt.args, tmpname, dir))
+			helperRmCheckFileContents(t, tmpname, tt.contents)
+			helperConvertCheckFileContents(t, tmpname, tt.bodies)
+		})
+	}
+}
+
+func TestSyncDryRun(t *testing.T) {
+	dir := helperSyncCreateDirectory(t, map[string]string{
+		"dir/text1.txt": "test 1",
+		"dir/text2.txt": "updated",
+		"new.txt":       "new file",
+	})
+	defer os.RemoveAll(dir)
+
+	// same mtime as the archive entry
+	mtime := time.Date(2018, 9, 17, 15, 43, 35, 0, time.FixedZone("", 9*60*60))
+	if err := os.Chtimes(filepath.Join(dir, "dir", "text1.txt"), mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+	cmd.SetArgs([]string{
+		"sync",
+		"--dry-run",
+		"--delete",
+		"../testcase/test.zip",
+		dir,
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	if stderr.Len() != 0 {
+		t.Fatalf("error output: %q", stderr.String())
+	}
+
+	want := "update: dir/text2.txt\nadd: new.txt\ndelete: text1.txt\n"
+	if out := stdout.String(); out != want {
+		t.Fatalf("output=%q, want %q", out, want)
+	}
+}
+
+func TestSyncDryRunValidate(t *testing.T) {
+	dir := helperSyncCreateDirectory(t, map[string]string{
+		"new.txt": "new file",
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "stdout",
+			args: []string{"sync", "--dry-run", "--out", "-"},
+			err:  "dry run cannot output archive to standard output\n",
+		},
+		{
+			name: "out_dir_with_overwrite",
+			args: []string{"sync", "--dry-run", "--overwrite", "--out-dir", dir},
+			err:  "output directory cannot be used with overwrite mode or output file name\n",
+		},
+	}
+
+	for _, tt := range tests {
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+
+		cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
+		cmd.SetArgs(append(tt.args, "../testcase/test.zip", dir))
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		if stderr.String() != tt.err {
+			t.Fatalf("%s: error output=%q, want %q", tt.name, stderr.String(), tt.err)
+		}
+		if stdout.Len() != 0 {
+			t.Fatalf("%s: output=%q, want nothing", tt.name, stdout.String())
+		}
+	}
+}
@@ -5,9 +5,25 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/hidez8891/zip"
 )
 
+// testEntry is an entry of the archives created by helperCreateArchive.
+// The entry is deflated unless stored is set.
+type testEntry struct {
+	name     string
+	body     string
+	stored   bool
+	mode     os.FileMode
+	modified time.Time
+	comment  string
+	extra    []byte
+}
+
 func copyTempFile(path string) (string, error) {
 	r, err := os.Open(path)
 	if err != nil {
@@ -27,13 +43,29 @@ func copyTempFile(path string) (string, error) {
 	return tmp.Name(), nil
 }
 
+func helperCopyFileTo(t *testing.T, src, dst string) string {
+	t.Helper()
+
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(dst, data, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
 func helperExecuteCommand(t *testing.T, args []string) {
 	t.Helper()
 
 	stdout := new(bytes.Buffer)
 	stderr := new(bytes.Buffer)
 
-	cmd := newRootCmd(stdout, stderr)
+	cmd := newRootCmd(new(bytes.Buffer), stdout, stderr)
 	cmd.SetArgs(args)
 	if err := cmd.Execute(); err != nil {
 		t.Fatal(err)
@@ -46,3 +78,47 @@ func helperExecuteCommand(t *testing.T, args []string) {
 		t.Fatalf("stdout output: %q", stdout.String())
 	}
 }
+
+// helperArchiveData returns an archive of entries in this order.
+func helperArchiveData(t *testing.T, entries []testEntry) []byte {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for _, e := range entries {
+		fh := &zip.FileHeader{
+			Name:     e.name,
+			Method:   zip.Deflate,
+			Modified: e.modified,
+			Comment:  e.comment,
+			Extra:    e.extra,
+		}
+		if e.stored {
+			fh.Method = zip.Store
+		}
+		if e.mode != 0 {
+			fh.SetMode(e.mode)
+		}
+
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// helperCreateArchive creates filename of entries in this order.
+func helperCreateArchive(t *testing.T, filename string, entries []testEntry) {
+	t.Helper()
+
+	if err := ioutil.WriteFile(filename, helperArchiveData(t, entries), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells limits the size of the table used to compute a text diff.
+const maxDiffCells = 16 * 1024 * 1024
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+	a, b int // line numbers before and after the operation
+}
+
+func splitLines(s string) []string {
+	if len(s) == 0 {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// unifiedDiff returns the differences of the lines in the unified format.
+func unifiedDiff(lines1, lines2 []string, name1, name2 string, context int) string {
+	if len(lines1)*len(lines2) > maxDiffCells {
+		return fmt.Sprintf("--- %s\n+++ %s\nfiles are too large to compare\n", name1, name2)
+	}
+
+	ops := diffLines(lines1, lines2)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "--- %s\n+++ %s\n", name1, name2)
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+
+		// extend the hunk while the changes are close to each other
+		last := first
+		for i := first; i < len(ops); i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*context {
+				break
+			}
+		}
+
+		begin := maxInt(first-context, start)
+		end := minInt(last+context+1, len(ops))
+		writeHunk(buf, ops[begin:end])
+		start = end
+	}
+	return buf.String()
+}
+
+func writeHunk(buf *bytes.Buffer, ops []diffOp) {
+	count1, count2 := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			count1++
+		}
+		if op.kind != '-' {
+			count2++
+		}
+	}
+
+	start1, start2 := ops[0].a, ops[0].b
+	if count1 != 0 {
+		start1++
+	}
+	if count2 != 0 {
+		start2++
+	}
+
+	fmt.Fprintf(buf, "@@ -%d,%d +%d,%d @@\n", start1, count1, start2, count2)
+	for _, op := range ops {
+		fmt.Fprintf(buf, "%c%s\n", op.kind, op.line)
+	}
+}
+
+// diffLines computes the shortest edit script by the longest common subsequence.
+func diffLines(lines1, lines2 []string) []diffOp {
+	n, m := len(lines1), len(lines2)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if lines1[i] == lines2[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && lines1[i] == lines2[j]:
+			ops = append(ops, diffOp{' ', lines1[i], i, j})
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', lines1[i], i, j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', lines2[j], i, j})
+			j++
+		}
+	}
+	return ops
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hidez8891/zip"
+	"github.com/spf13/cobra"
+)
+
+func newTouchCmd(params *cmdParams) *cobra.Command {
+	touchcmd := &touch{
+		baseCmd: &baseCmd{params},
+		pexe:    &toolParallelCmd{params: params},
+	}
+
+	var cmd = &cobra.Command{
+		Use:   "touch [filepath...]",
+		Short: "Change modification time of files",
+		Args:  params.requireInputs,
+		Run: func(cmd *cobra.Command, args []string) {
+			touchcmd.run(cmd, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&touchcmd.time, "time", "", "modification time (e.g. \"2006-01-02 15:04:05\", default now)")
+	cmd.Flags().StringVar(&touchcmd.reference, "reference-entry", "", "use modification time of the entry")
+	cmd.Flags().BoolVar(&touchcmd.fromArchive, "from-archive-mtime", false, "use modification time of the archive file")
+	cmd.Flags().DurationVar(&touchcmd.shift, "shift", 0, "shift modification time by duration (e.g. -1h30m)")
+	touchcmd.pexe.setFlags(cmd)
+	return cmd
+}
+
+type touch struct {
+	*baseCmd
+	pexe        *toolParallelCmd
+	time        string
+	reference   string
+	fromArchive bool
+	shift       time.Duration
+	now         time.Time
+}
+
+func (o *touch) run(cmd *cobra.Command, args []string) {
+	paths, err := o.expandFilePath(args)
+	if err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+
+	if err := o.validate(cmd); err != nil {
+		fmt.Fprintln(o.stderr, err)
+		return
+	}
+	if ok, err := o.validateOutputFlag(paths); !ok {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+	if err := o.pexe.flagValidate(); err != nil {
+		fmt.Fprintln(o.stderr, err.Error())
+		return
+	}
+
+	errors := o.pexe.execute(paths, func(filepath string) error {
+		return o.execute(filepath)
+	})
+
+	if errors != nil {
+		for _, err := range errors {
+			fmt.Fprintln(o.stderr, err.Error())
+		}
+	}
+}
+
+func (o *touch) validate(cmd *cobra.Command) error {
+	sources := 0
+	for _, ok := range []bool{len(o.time) != 0, len(o.reference) != 0, o.fromArchive} {
+		if ok {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of --time, --reference-entry and --from-archive-mtime can be used")
+	}
+
+	o.now = time.Now()
+	if len(o.time) != 0 {
+		t, err := parseTime(o.time)
+		if err != nil {
+			return err
+		}
+		o.now = t
+	}
+
+	// shift only moves the current time of each entry
+	if sources == 0 && cmd.Flags().Changed("shift") {
+		o.now = time.Time{}
+	}
+	return nil
+}
+
+func (o *touch) execute(filepath string) error {
+	base := o.now
+	if o.fromArchive {
+		file := parseArchivePath(filepath).file
+		if file == stdioPath {
+			return fmt.Errorf("standard input has no modification time")
+		}
+		st, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		base = st.ModTime()
+	}
+
+	return o.editZipFile(filepath, func(zu *zip.Updater, filter pathFilter) (bool, error) {
+		base := base
+		if len(o.reference) != 0 {
+			header := findHeader(zu, o.reference)
+			if header == nil {
+				return false, fmt.Errorf("%s: reference entry not found", o.reference)
+			}
+			base = headerModTime(header)
+		}
+
+		isModified := false
+		for _, header := range zu.Files() {
+			ok, err := filter(header)
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				continue
+			}
+
+			t := base
+			if t.IsZero() {
+				t = headerModTime(header)
+			}
+			setModTime(header, t.Add(o.shift))
+			isModified = true
+		}
+		return isModified, nil
+	})
+}
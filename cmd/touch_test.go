@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hidez8891/zip"
+)
+
+func TestTouchExecuteOverwrite(t *testing.T) {
+	fixed := time.Date(2020, 1, 2, 3, 4, 6, 0, time.Local)
+	archiveTime := time.Date(2019, 5, 6, 7, 8, 10, 0, time.Local)
+	original := helperTouchModTimes(t, "../testcase/test.zip")
+
+	tests := []struct {
+		name  string
+		args  []string
+		times map[string]time.Time
+	}{
+		{
+			name: "time",
+			args: []string{
+				"touch",
+				"--overwrite",
+				"--time",
+				"2020-01-02 03:04:06",
+				"--filter",
+				"text1.txt",
+				"--show-progress=false",
+			},
+			times: map[string]time.Time{
+				"dir/text1.txt": original["dir/text1.txt"],
+				"text1.txt":     fixed,
+			},
+		},
+		{
+			name: "reference_entry",
+			args: []string{
+				"touch",
+				"--overwrite",
+				"--reference-entry",
+				"dir/text1.txt",
+				"--show-progress=false",
+			},
+			times: map[string]time.Time{
+				"dir/":          original["dir/text1.txt"],
+				"dir/text2.txt": original["dir/text1.txt"],
+				"text1.txt":     original["dir/text1.txt"],
+			},
+		},
+		{
+			name: "shift",
+			args: []string{
+				"touch",
+				"--overwrite",
+				"--shift",
+				"-1h",
+				"--show-progress=false",
+			},
+			times: map[string]time.Time{
+				"dir/text1.txt": original["dir/text1.txt"].Add(-time.Hour),
+				"text1.txt":     original["text1.txt"].Add(-time.Hour),
+			},
+		},
+		{
+			name: "from_archive_mtime_with_shift",
+			args: []string{
+				"touch",
+				"--overwrite",
+				"--from-archive-mtime",
+				"--shift",
+				"2s",
+				"--filter",
+				"dir/*",
+				"--show-progress=false",
+			},
+			times: map[string]time.Time{
+				"dir/text1.txt": archiveTime.Add(2 * time.Second),
+				"text1.txt":     original["text1.txt"],
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tmpname, err := copyTempFile("../testcase/test.zip")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(tmpname)
+			if err := os.Chtimes(tmpname, archiveTime, archiveTime); err != nil {
+				t.Fatal(err)
+			}
+
+			helperExecuteCommand(t, append(tt.args, tmpname))
+
+			times := helperTouchModTimes(t, tmpname)
+			for name, want := range tt.times {
+				if !times[name].Truncate(time.Second).Equal(want.Truncate(time.Second)) {
+					t.Fatalf("%s: mtime=%v, want %v", name, times[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestTouchWritesBothTimeFields(t *testing.T) {
+	tmpname, err := copyTempFile("../testcase/test.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpname)
+
+	helperExecuteCommand(t, []string{"touch", "--overwrite", "--time", "2020-01-02 03:04:06", "--show-progress=false", tmpname})
+
+	zr, err := zip.OpenReader(tmpname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	want := time.Date(2020, 1, 2, 3, 4, 6, 0, time.Local)
+	for _, zf := range zr.File {
+		dos := zf.ModTime()
+		if dos.Year() != 2020 || dos.Hour() != 3 || dos.Second() != 6 {
+			t.Fatalf("%s: MS-DOS time=%v, want %v", zf.Name, dos, want)
+		}
+
+		count := 0
+		for extra := zf.Extra; len(extra) >= 4; {
+			size := int(binary.LittleEndian.Uint16(extra[2:])) + 4
+			if binary.LittleEndian.Uint16(extra) == extTimeExtraID {
+				count++
+				if unix := int64(binary.LittleEndian.Uint32(extra[5:])); unix != want.Unix() {
+					t.Fatalf("%s: extended time=%d, want %d", zf.Name, unix, want.Unix())
+				}
+			}
+			extra = extra[size:]
+		}
+		if count != 1 {
+			t.Fatalf("%s: extended timestamp count=%d, want 1", zf.Name, count)
+		}
+	}
+}
+
+func TestSetModTimeOutOfRange(t *testing.T) {
+	tests := []struct {
+		time time.Time
+		year int
+	}{
+		{time.Date(1960, 1, 2, 3, 4, 6, 0, time.UTC), 1980},
+		{time.Date(2200, 1, 2, 3, 4, 6, 0, time.UTC), 2107},
+	}
+
+	for _, tt := range tests {
+		h := &zip.FileHeader{Name: "a.txt"}
+		setModTime(h, time.Date(2020, 1, 2, 3, 4, 6, 0, time.UTC))
+		setModTime(h, tt.time)
+
+		if len(removeExtraField(h.Extra, extTimeExtraID)) != len(h.Extra) {
+			t.Fatalf("%v: extended timestamp is written", tt.time)
+		}
+		if year := headerModTime(h).Year(); year != tt.year {
+			t.Fatalf("%v: year=%d, want %d", tt.time, year, tt.year)
+		}
+	}
+}
+
+func helperTouchModTimes(t *testing.T, filename string) map[string]time.Time {
+	t.Helper()
+
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+
+	times := make(map[string]time.Time)
+	for _, zf := range zr.File {
+		times[zf.Name] = headerModTime(&zf.FileHeader)
+	}
+	return times
+}